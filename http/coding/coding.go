@@ -0,0 +1,121 @@
+// Package coding implements codings shared by Transfer-Encoding and Content-Encoding.
+//
+// Codings are kept in a registry. gzip, deflate, compress and identity are registered by default
+// and embedders can register more like br or zstd by Register.
+package coding
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupported is returned for coding not registered.
+var ErrUnsupported = errors.New("coding: unsupported coding")
+
+// Coding is a coding with its decoder and encoder.
+type Coding struct {
+	// Name is coding name registered in IANA HTTP Content Coding Registry like "gzip".
+	Name string
+	// NewReader returns reader decoding r.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+	// NewWriter returns writer encoding to w. Close must write rest of encoded data without closing w.
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+}
+
+// aliases are names treated as same coding (RFC 9110 8.4.1).
+var aliases = map[string]string{
+	"x-gzip":     "gzip",
+	"x-compress": "compress",
+}
+
+var (
+	mu      sync.RWMutex
+	codings = map[string]Coding{}
+	names   = []string{}
+)
+
+func init() {
+	Register(Coding{
+		Name:      "gzip",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+	})
+	// deflate is zlib format (RFC 9110 8.4.1.2)
+	Register(Coding{
+		Name:      "deflate",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return zlib.NewReader(r) },
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil },
+	})
+	Register(Coding{
+		Name:      "compress",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return newCompressReader(r) },
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return newCompressWriter(w), nil },
+	})
+	Register(Coding{
+		Name:      "identity",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return ioutil.NopCloser(r), nil },
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return nopCloser{w}, nil },
+	})
+}
+
+// Register adds coding. Coding having same name is replaced.
+func Register(c Coding) {
+	mu.Lock()
+	defer mu.Unlock()
+	name := strings.ToLower(c.Name)
+	if _, ok := codings[name]; !ok {
+		names = append(names, name)
+	}
+	codings[name] = c
+}
+
+// Lookup returns registered coding. name is case-insensitive.
+func Lookup(name string) (Coding, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := codings[name]
+	return c, ok
+}
+
+// Names returns names of registered codings in order of registration.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]string{}, names...)
+}
+
+// NewReader returns reader decoding r with coding name.
+func NewReader(name string, r io.Reader) (io.ReadCloser, error) {
+	c, ok := Lookup(name)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return c.NewReader(r)
+}
+
+// NewWriter returns writer encoding to w with coding name.
+// Close must be called to write rest of encoded data. It doesn't close w.
+func NewWriter(name string, w io.Writer) (io.WriteCloser, error) {
+	c, ok := Lookup(name)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return c.NewWriter(w)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
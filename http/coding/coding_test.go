@@ -0,0 +1,145 @@
+package coding
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	large := make([]byte, 1000000) // code width grows up to 16 bits and table gets full
+	for i := range large {
+		large[i] = byte('a' + r.Intn(1+i%13))
+	}
+	inputs := [][]byte{{}, []byte("a"), []byte("TOBEORNOTTOBEORTOBEORNOT"), bytes.Repeat([]byte("a"), 10000), large}
+	for _, name := range []string{"gzip", "deflate", "compress", "identity", "X-GZIP"} {
+		for _, in := range inputs {
+			var b bytes.Buffer
+			w, err := NewWriter(name, &b)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			w.Write(in)
+			if err := w.Close(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			rd, err := NewReader(name, &b)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			out, err := ioutil.ReadAll(rd)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Equal(in, out) {
+				t.Errorf("Unexpected decoded length:%v coding:%v", len(out), name)
+			}
+		}
+	}
+}
+
+func TestCompressReader_Clear(t *testing.T) {
+	// a, b, CLEAR, padding to 8 codes, c, c, 257(cc)
+	var b bytes.Buffer
+	w := &compressWriter{w: &b, nbits: compressInitBits, ent: -1}
+	for _, code := range []int{'a', 'b', compressClear, 0, 0, 0, 0, 0, 'c', 'c', 257} {
+		w.writeCode(code)
+	}
+	w.Close()
+	data := append([]byte{compressMagic0, compressMagic1, compressBlockMode | compressMaxBits}, b.Bytes()...)
+
+	rd, err := NewReader("compress", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out, err := ioutil.ReadAll(rd)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(out) != "abcccc" {
+		t.Errorf("Unexpected decoded: %v", string(out))
+	}
+}
+
+func TestCompressReader_Invalid(t *testing.T) {
+	for _, data := range [][]byte{{}, {0x1f, 0x8b, 0x90}, {0x1f, 0x9d, 0x88}, {0x1f, 0x9d, 0x90, 0xff, 0xff}} {
+		rd, err := NewReader("compress", bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+		if _, err := ioutil.ReadAll(rd); err == nil {
+			t.Errorf("Unexpected success: %v", data)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	if _, err := NewReader("br", &bytes.Buffer{}); err != ErrUnsupported {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := NewWriter("br", &bytes.Buffer{}); err != ErrUnsupported {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	reversed := Coding{
+		Name: "X-Reverse",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			b, err := ioutil.ReadAll(r)
+			return ioutil.NopCloser(bytes.NewReader(reverse(b))), err
+		},
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return &reverseWriter{w: w}, nil
+		},
+	}
+	Register(reversed)
+
+	var b bytes.Buffer
+	w, err := NewWriter("x-reverse", &b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w.Write([]byte("abc"))
+	w.Close()
+	if b.String() != "cba" {
+		t.Errorf("Unexpected encoded: %v", b.String())
+	}
+	r, err := NewReader("X-REVERSE", &b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out, _ := ioutil.ReadAll(r)
+	if string(out) != "abc" {
+		t.Errorf("Unexpected decoded: %v", string(out))
+	}
+	names := Names()
+	if names[len(names)-1] != "x-reverse" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+}
+
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+type reverseWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (w *reverseWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *reverseWriter) Close() error {
+	_, err := w.w.Write(reverse(w.buf.Bytes()))
+	return err
+}
@@ -0,0 +1,277 @@
+package coding
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// compress(1) format used by "compress" coding (RFC 9110 8.4.1.1).
+// It starts with magic 0x1f 0x9d and a flag byte having max code width and block mode bit.
+// LZW codes follow packed from least significant bit. Code width starts from 9 bits and
+// grows up to max code width. When width grows or table is cleared, rest of the current
+// group of 8 codes is skipped.
+const (
+	compressMagic0    = 0x1f
+	compressMagic1    = 0x9d
+	compressBlockMode = 0x80
+	compressBitsMask  = 0x1f
+	compressInitBits  = 9
+	compressMaxBits   = 16
+	compressClear     = 256
+	compressFirst     = 257
+)
+
+var errInvalidCompress = errors.New("coding: invalid compress data")
+
+type compressReader struct {
+	r       *bufio.Reader
+	acc     uint32
+	nacc    uint
+	nbits   uint
+	maxbits uint
+	block   bool
+	codes   int // codes read with current width
+	free    int
+	prev    int
+	finchar byte
+	prefix  []uint16
+	suffix  []byte
+	stack   []byte
+	out     []byte
+	err     error
+}
+
+func newCompressReader(r io.Reader) (*compressReader, error) {
+	br := bufio.NewReader(r)
+	h := make([]byte, 3)
+	if _, err := io.ReadFull(br, h); err != nil {
+		return nil, errInvalidCompress
+	}
+	maxbits := uint(h[2] & compressBitsMask)
+	if h[0] != compressMagic0 || h[1] != compressMagic1 || maxbits < compressInitBits || maxbits > compressMaxBits {
+		return nil, errInvalidCompress
+	}
+	cr := &compressReader{
+		r:       br,
+		nbits:   compressInitBits,
+		maxbits: maxbits,
+		block:   h[2]&compressBlockMode != 0,
+		prev:    -1,
+		prefix:  make([]uint16, 1<<maxbits),
+		suffix:  make([]byte, 1<<maxbits),
+	}
+	for i := 0; i < 256; i++ {
+		cr.suffix[i] = byte(i)
+	}
+	cr.reset()
+	return cr, nil
+}
+
+func (cr *compressReader) Read(p []byte) (int, error) {
+	for len(cr.out) == 0 && cr.err == nil {
+		cr.err = cr.decode()
+	}
+	n := copy(p, cr.out)
+	cr.out = cr.out[n:]
+	if n > 0 {
+		return n, nil
+	}
+	return 0, cr.err
+}
+
+func (cr *compressReader) Close() error {
+	return nil
+}
+
+func (cr *compressReader) reset() {
+	cr.nbits = compressInitBits
+	cr.prev = -1
+	cr.free = 256
+	if cr.block {
+		cr.free = compressFirst
+	}
+}
+
+// decode reads one code and sets its string to out.
+func (cr *compressReader) decode() error {
+	if cr.free > 1<<cr.nbits-1 && cr.nbits < cr.maxbits {
+		if err := cr.align(); err != nil {
+			return err
+		}
+		cr.nbits++
+	}
+	code, err := cr.readCode()
+	if err != nil {
+		return err
+	}
+	if code == compressClear && cr.block {
+		if err := cr.align(); err != nil {
+			return err
+		}
+		cr.reset()
+		return nil
+	}
+	if cr.prev == -1 {
+		if code >= 256 {
+			return errInvalidCompress
+		}
+		cr.prev = code
+		cr.finchar = byte(code)
+		cr.out = append(cr.out[:0], cr.finchar)
+		return nil
+	}
+
+	in := code
+	stack := cr.stack[:0]
+	if code >= cr.free {
+		// KwKwK case: code is being defined by this step
+		if code > cr.free {
+			return errInvalidCompress
+		}
+		stack = append(stack, cr.finchar)
+		code = cr.prev
+	}
+	for code >= 256 {
+		stack = append(stack, cr.suffix[code])
+		code = int(cr.prefix[code])
+	}
+	cr.finchar = byte(code)
+	stack = append(stack, cr.finchar)
+	cr.stack = stack
+
+	cr.out = cr.out[:0]
+	for i := len(stack) - 1; i >= 0; i-- {
+		cr.out = append(cr.out, stack[i])
+	}
+	if cr.free < 1<<cr.maxbits {
+		cr.prefix[cr.free] = uint16(cr.prev)
+		cr.suffix[cr.free] = cr.finchar
+		cr.free++
+	}
+	cr.prev = in
+	return nil
+}
+
+// readCode returns io.EOF if rest of input is shorter than a code.
+func (cr *compressReader) readCode() (int, error) {
+	for cr.nacc < cr.nbits {
+		b, err := cr.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		cr.acc |= uint32(b) << cr.nacc
+		cr.nacc += 8
+	}
+	code := int(cr.acc & (1<<cr.nbits - 1))
+	cr.acc >>= cr.nbits
+	cr.nacc -= cr.nbits
+	cr.codes++
+	return code, nil
+}
+
+// align skips rest of the current group of 8 codes.
+func (cr *compressReader) align() error {
+	for cr.codes%8 != 0 {
+		if _, err := cr.readCode(); err != nil {
+			return err
+		}
+	}
+	cr.codes = 0
+	return nil
+}
+
+type compressWriter struct {
+	w     io.Writer
+	buf   []byte
+	acc   uint32
+	nacc  uint
+	nbits uint
+	codes int // codes written with current width
+	free  int
+	ent   int
+	table map[uint32]int
+	err   error
+}
+
+func newCompressWriter(w io.Writer) *compressWriter {
+	return &compressWriter{
+		w:     w,
+		buf:   []byte{compressMagic0, compressMagic1, compressBlockMode | compressMaxBits},
+		nbits: compressInitBits,
+		free:  compressFirst,
+		ent:   -1,
+		table: map[uint32]int{},
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	for _, c := range p {
+		if cw.ent == -1 {
+			cw.ent = int(c)
+			continue
+		}
+		key := uint32(cw.ent)<<8 | uint32(c)
+		if code, ok := cw.table[key]; ok {
+			cw.ent = code
+			continue
+		}
+		cw.writeCode(cw.ent)
+		if cw.free < 1<<compressMaxBits {
+			cw.table[key] = cw.free
+			cw.free++
+		}
+		cw.ent = int(c)
+		if cw.free > 1<<cw.nbits && cw.nbits < compressMaxBits {
+			// reader grows width at the same code
+			for cw.codes%8 != 0 {
+				cw.writeCode(0)
+			}
+			cw.codes = 0
+			cw.nbits++
+		}
+	}
+	if len(cw.buf) >= 4096 {
+		cw.flushBuf()
+	}
+	return len(p), cw.err
+}
+
+func (cw *compressWriter) Close() error {
+	if cw.err != nil {
+		return cw.err
+	}
+	if cw.ent != -1 {
+		cw.writeCode(cw.ent)
+		cw.ent = -1
+	}
+	if cw.nacc > 0 {
+		cw.buf = append(cw.buf, byte(cw.acc))
+		cw.acc = 0
+		cw.nacc = 0
+	}
+	cw.flushBuf()
+	return cw.err
+}
+
+func (cw *compressWriter) writeCode(code int) {
+	cw.acc |= uint32(code) << cw.nacc
+	cw.nacc += cw.nbits
+	for cw.nacc >= 8 {
+		cw.buf = append(cw.buf, byte(cw.acc))
+		cw.acc >>= 8
+		cw.nacc -= 8
+	}
+	cw.codes++
+}
+
+func (cw *compressWriter) flushBuf() {
+	if len(cw.buf) == 0 || cw.err != nil {
+		return
+	}
+	_, cw.err = cw.w.Write(cw.buf)
+	cw.buf = cw.buf[:0]
+}
@@ -0,0 +1,41 @@
+package header
+
+import "sort"
+
+// AcceptCharset is a charset of Accept-Charset (RFC 9110 12.5.2).
+type AcceptCharset struct {
+	// Charset is charset name like "utf-8" or "*".
+	Charset string
+	Weight  float64
+}
+
+// ParseAcceptCharset returns charsets in order of weight. Charsets with q=0 are included.
+// Invalid charsets are skipped and valid ones are returned with the first error.
+//
+//	Accept-Charset = #( ( token / "*" ) [ weight ] )
+func ParseAcceptCharset(headerValue string) ([]AcceptCharset, error) {
+	charsets := []AcceptCharset{}
+	err := ParseListLenient(headerValue, func(l *Lexer) error {
+		c, err := l.Token()
+		if err != nil {
+			return err
+		}
+		params, err := l.Parameters()
+		if err != nil {
+			return err
+		}
+		q, err := Weight(params)
+		if err != nil {
+			return l.Errorf("weight of %v is invalid", c)
+		}
+		if err := l.EndOfElement(); err != nil {
+			return err
+		}
+		charsets = append(charsets, AcceptCharset{Charset: c, Weight: q})
+		return nil
+	})
+	sort.SliceStable(charsets, func(i, j int) bool {
+		return charsets[i].Weight > charsets[j].Weight
+	})
+	return charsets, err
+}
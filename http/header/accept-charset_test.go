@@ -0,0 +1,28 @@
+package header
+
+import "testing"
+
+func TestParseAcceptCharset(t *testing.T) {
+	actual, err := ParseAcceptCharset("iso-8859-5;q=0.5, unicode-1-1;q=0.8, utf-8, *;q=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []AcceptCharset{{"utf-8", 1}, {"unicode-1-1", 0.8}, {"iso-8859-5", 0.5}, {"*", 0}}
+	if len(actual) != len(expected) {
+		t.Fatalf("Unexpected result: %v.", actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Unexpected charset: %v.", actual[i])
+		}
+	}
+}
+
+func TestParseAcceptCharset_Invalid(t *testing.T) {
+	for _, v := range []string{"utf-8;q=1.001", "\"utf-8\"", "utf-8;q"} {
+		if actual, err := ParseAcceptCharset(v); err == nil {
+			t.Errorf("Unexpected result: %v for %v.", actual, v)
+		}
+	}
+}
@@ -2,8 +2,6 @@ package header
 
 import (
 	"sort"
-	"strconv"
-	"strings"
 )
 
 type AcceptEncoding struct {
@@ -11,36 +9,49 @@ type AcceptEncoding struct {
 	Weight float64
 }
 
-func ParseAcceptEncoding(headerValue string) []AcceptEncoding {
-
+// parseAcceptEncoding returns codings in order of appearance including "*" and q=0.
+// Invalid codings are skipped and valid ones are returned with the first error.
+//
+//	Accept-Encoding = #( codings [ weight ] )
+//	codings         = content-coding / "identity" / "*"
+func parseAcceptEncoding(headerValue string) ([]AcceptEncoding, error) {
 	acceptEncodings := []AcceptEncoding{}
-	wildCardQ := 0.0
-
-	for _, val := range strings.Split(headerValue, ",") {
-		sp := strings.Split(val, ";")
-		ccstr := strings.TrimSpace(sp[0])
-		cc := getContentCoding(ccstr)
-		if len(sp) == 1 {
-			if ccstr == "*" {
-				wildCardQ = 1
-				continue
-			}
-			acceptEncodings = append(acceptEncodings, AcceptEncoding{Coding: cc, Weight: 1})
-			continue
+	err := ParseListLenient(headerValue, func(l *Lexer) error {
+		coding, err := l.Token()
+		if err != nil {
+			return err
 		}
-
-		params := strings.Split(sp[1], "=")
-		q := 0.0
-		if len(params) == 0 {
-			q = 1
-		} else {
-			q, _ = strconv.ParseFloat(strings.TrimSpace(params[1]), 64)
+		params, err := l.Parameters()
+		if err != nil {
+			return err
+		}
+		q, err := Weight(params)
+		if err != nil {
+			return l.Errorf("weight of %v is invalid", coding)
+		}
+		if err := l.EndOfElement(); err != nil {
+			return err
 		}
-		if ccstr == "*" {
-			wildCardQ = q
+		acceptEncodings = append(acceptEncodings, AcceptEncoding{Coding: getContentCoding(coding), Weight: q})
+		return nil
+	})
+	return acceptEncodings, err
+}
+
+// ParseAcceptEncoding returns acceptable codings in order of weight.
+// "*" is expanded to codings not listed. If nothing is acceptable, it returns identity.
+// Invalid codings are skipped and the first error is returned with valid ones.
+func ParseAcceptEncoding(headerValue string) ([]AcceptEncoding, error) {
+	parsed, err := parseAcceptEncoding(headerValue)
+
+	acceptEncodings := []AcceptEncoding{}
+	wildCardQ := 0.0
+	for _, ae := range parsed {
+		if ae.Coding == "*" {
+			wildCardQ = ae.Weight
 			continue
 		}
-		acceptEncodings = append(acceptEncodings, AcceptEncoding{Coding: cc, Weight: q})
+		acceptEncodings = append(acceptEncodings, ae)
 	}
 
 	if wildCardQ != 0 {
@@ -52,7 +63,7 @@ func ParseAcceptEncoding(headerValue string) []AcceptEncoding {
 		}
 	}
 
-	sort.Slice(acceptEncodings, func(i, j int) bool {
+	sort.SliceStable(acceptEncodings, func(i, j int) bool {
 		return acceptEncodings[i].Weight > acceptEncodings[j].Weight
 	})
 
@@ -64,10 +75,10 @@ func ParseAcceptEncoding(headerValue string) []AcceptEncoding {
 	}
 
 	if len(without0) == 0 {
-		return []AcceptEncoding{{Coding: CONTENT_CODING_IDENTITY, Weight: 1}}
+		return []AcceptEncoding{{Coding: CONTENT_CODING_IDENTITY, Weight: 1}}, err
 	}
 
-	return without0
+	return without0, err
 }
 
 func containsCoding(aes []AcceptEncoding, c ContentCoding) bool {
@@ -79,3 +90,51 @@ func containsCoding(aes []AcceptEncoding, c ContentCoding) bool {
 
 	return false
 }
+
+// NegotiateContentCoding returns the coding in available most preferred by Accept-Encoding headers (RFC 9110 12.5.3).
+// Ties are broken by order of available. identity is acceptable unless it's excluded by "identity;q=0" or "*;q=0".
+// Invalid codings of Accept-Encoding are disregarded.
+// It returns false if nothing in available and identity is acceptable.
+func (h Headers) NegotiateContentCoding(available []ContentCoding) (ContentCoding, bool) {
+	// invalid codings are skipped by parser
+	parsed, _ := parseAcceptEncoding(h.Combined("ACCEPT-ENCODING"))
+	if !h.Has("ACCEPT-ENCODING") {
+		// any coding is acceptable but not requested
+		return CONTENT_CODING_IDENTITY, true
+	}
+
+	weights := map[ContentCoding]float64{}
+	wildcard := -1.0
+	for _, ae := range parsed {
+		if ae.Coding == "*" {
+			wildcard = ae.Weight
+			continue
+		}
+		weights[ae.Coding] = ae.Weight
+	}
+
+	weight := func(c ContentCoding) float64 {
+		if q, ok := weights[c]; ok {
+			return q
+		}
+		if wildcard >= 0 {
+			return wildcard
+		}
+		if c == CONTENT_CODING_IDENTITY {
+			// acceptable but least preferred
+			return 0.0001
+		}
+		return 0
+	}
+
+	var best ContentCoding
+	bestWeight := 0.0
+	candidates := append(append([]ContentCoding{}, available...), CONTENT_CODING_IDENTITY)
+	for _, c := range candidates {
+		if w := weight(c); w > bestWeight {
+			best = c
+			bestWeight = w
+		}
+	}
+	return best, bestWeight > 0
+}
@@ -10,8 +10,17 @@ func assertAcceptEncoding(t *testing.T, actual AcceptEncoding, expectedContentCo
 		t.Errorf("Unexpected weight: %v.", actual.Weight)
 	}
 }
+
+func parseAcceptEncodingForTest(t *testing.T, v string) []AcceptEncoding {
+	actual, err := ParseAcceptEncoding(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return actual
+}
+
 func TestParseAcceptEncoding_Multiple(t *testing.T) {
-	actual := ParseAcceptEncoding("compress, gzip")
+	actual := parseAcceptEncodingForTest(t, "compress, gzip")
 
 	if len(actual) != 2 {
 		t.Errorf("Unexpected result: %v.", actual)
@@ -21,7 +30,7 @@ func TestParseAcceptEncoding_Multiple(t *testing.T) {
 }
 
 func TestParseAcceptEncoding_Priority(t *testing.T) {
-	actual := ParseAcceptEncoding("compress;q=0.5, gzip;q=1.0")
+	actual := parseAcceptEncodingForTest(t, "compress;q=0.5, gzip;q=1.0")
 
 	if len(actual) != 2 {
 		t.Errorf("Unexpected result: %v.", actual)
@@ -31,7 +40,7 @@ func TestParseAcceptEncoding_Priority(t *testing.T) {
 }
 
 func TestParseAcceptEncoding_WildCard(t *testing.T) {
-	actual := ParseAcceptEncoding("*")
+	actual := parseAcceptEncodingForTest(t, "*")
 
 	if len(actual) != 4 {
 		t.Errorf("Unexpected result: %v.", actual)
@@ -43,7 +52,7 @@ func TestParseAcceptEncoding_WildCard(t *testing.T) {
 }
 
 func TestParseAcceptEncoding_Complex1(t *testing.T) {
-	actual := ParseAcceptEncoding("gzip;q=1.0, identity; q=0.5, *;q=0")
+	actual := parseAcceptEncodingForTest(t, "gzip;q=1.0, identity; q=0.5, *;q=0")
 
 	if len(actual) != 2 {
 		t.Errorf("Unexpected result: %v.", actual)
@@ -53,7 +62,7 @@ func TestParseAcceptEncoding_Complex1(t *testing.T) {
 }
 
 func TestParseAcceptEncoding_Complex2(t *testing.T) {
-	actual := ParseAcceptEncoding("gzip;q=1.0, identity; q=0.5, compress; q=0, *;q=0.3")
+	actual := parseAcceptEncodingForTest(t, "gzip;q=1.0, identity; q=0.5, compress; q=0, *;q=0.3")
 
 	if len(actual) != 3 {
 		t.Errorf("Unexpected result: %v.", actual)
@@ -64,10 +73,61 @@ func TestParseAcceptEncoding_Complex2(t *testing.T) {
 }
 
 func TestParseAcceptEncoding_AllDeny(t *testing.T) {
-	actual := ParseAcceptEncoding("gzip;q=0")
+	actual := parseAcceptEncodingForTest(t, "gzip;q=0")
 
 	if len(actual) != 1 {
 		t.Errorf("Unexpected result: %v.", actual)
 	}
 	assertAcceptEncoding(t, actual[0], CONTENT_CODING_IDENTITY, 1)
 }
+
+func TestParseAcceptEncoding_Unknown(t *testing.T) {
+	actual := parseAcceptEncodingForTest(t, "br, X-GZIP;q=0.5")
+
+	if len(actual) != 2 {
+		t.Errorf("Unexpected result: %v.", actual)
+	}
+	assertAcceptEncoding(t, actual[0], ContentCoding("br"), 1)
+	assertAcceptEncoding(t, actual[1], CONTENT_CODING_GZIP, 0.5)
+}
+
+func TestParseAcceptEncoding_Invalid(t *testing.T) {
+	for _, v := range []string{"gzip;q=", "gzip;q=1.5", "gzip;q=.5", "gzip br"} {
+		if actual, err := ParseAcceptEncoding(v); err == nil {
+			t.Errorf("Unexpected result: %v for %v.", actual, v)
+		}
+	}
+}
+
+func TestNegotiateContentCoding(t *testing.T) {
+	available := []ContentCoding{CONTENT_CODING_GZIP, CONTENT_CODING_DEFLATE, CONTENT_CODING_COMPRESS}
+	tests := []struct {
+		acceptEncoding string
+		expected       ContentCoding
+		ok             bool
+	}{
+		{"", CONTENT_CODING_IDENTITY, true},
+		{"deflate, gzip", CONTENT_CODING_GZIP, true},
+		{"deflate, gzip;q=0.5", CONTENT_CODING_DEFLATE, true},
+		{"br", CONTENT_CODING_IDENTITY, true},
+		{"gzip;q=0.5, identity", CONTENT_CODING_IDENTITY, true},
+		{"*", CONTENT_CODING_GZIP, true},
+		{"*;q=0.5, gzip;q=0", CONTENT_CODING_DEFLATE, true},
+		{"identity;q=0", "", false},
+		{"br, identity;q=0", "", false},
+		{"*;q=0", "", false},
+		{"*;q=0, identity", CONTENT_CODING_IDENTITY, true},
+		{"X-Compress", CONTENT_CODING_COMPRESS, true},
+		{"gzip;q", CONTENT_CODING_IDENTITY, true}, // invalid header is disregarded
+	}
+	for _, tt := range tests {
+		h := Headers{}
+		if tt.acceptEncoding != "" {
+			h.Add("ACCEPT-ENCODING", tt.acceptEncoding)
+		}
+		actual, ok := h.NegotiateContentCoding(available)
+		if actual != tt.expected || ok != tt.ok {
+			t.Errorf("Unexpected coding: %v %v for %v.", actual, ok, tt.acceptEncoding)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package header
+
+import (
+	"sort"
+	"strings"
+)
+
+// AcceptLanguage is a language range of Accept-Language (RFC 9110 12.5.4, RFC 4647 2.1).
+type AcceptLanguage struct {
+	// Range is language range like "en-US" or "*".
+	Range  string
+	Weight float64
+}
+
+// ParseAcceptLanguage returns language ranges in order of weight. Ranges with q=0 are included.
+// Invalid ranges are skipped and valid ones are returned with the first error.
+//
+//	Accept-Language = #( language-range [ weight ] )
+func ParseAcceptLanguage(headerValue string) ([]AcceptLanguage, error) {
+	languages := []AcceptLanguage{}
+	err := ParseListLenient(headerValue, func(l *Lexer) error {
+		r, err := l.Token()
+		if err != nil {
+			return err
+		}
+		if !isLanguageRange(r) {
+			return l.Errorf("language range %v is invalid", r)
+		}
+		params, err := l.Parameters()
+		if err != nil {
+			return err
+		}
+		q, err := Weight(params)
+		if err != nil {
+			return l.Errorf("weight of %v is invalid", r)
+		}
+		if err := l.EndOfElement(); err != nil {
+			return err
+		}
+		languages = append(languages, AcceptLanguage{Range: r, Weight: q})
+		return nil
+	})
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].Weight > languages[j].Weight
+	})
+	return languages, err
+}
+
+// language-range = (1*8ALPHA *("-" 1*8alphanum)) / "*"
+func isLanguageRange(r string) bool {
+	if r == "*" {
+		return true
+	}
+	for i, subtag := range strings.Split(r, "-") {
+		if len(subtag) == 0 || len(subtag) > 8 {
+			return false
+		}
+		for _, c := range subtag {
+			alpha := ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+			digit := '0' <= c && c <= '9'
+			if !alpha && (i == 0 || !digit) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package header
+
+import "testing"
+
+func TestParseAcceptLanguage(t *testing.T) {
+	actual, err := ParseAcceptLanguage("da, en-gb;q=0.8, en;q=0.7, *;q=0, zh-Hant-TW")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []AcceptLanguage{{"da", 1}, {"zh-Hant-TW", 1}, {"en-gb", 0.8}, {"en", 0.7}, {"*", 0}}
+	if len(actual) != len(expected) {
+		t.Fatalf("Unexpected result: %v.", actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Unexpected language: %v.", actual[i])
+		}
+	}
+}
+
+func TestGetAcceptLanguages_MultipleHeaders(t *testing.T) {
+	h := NewHeaders(Header{FieldName: "ACCEPT-LANGUAGE", FieldValue: "en;q=0.5"}, Header{FieldName: "ACCEPT-LANGUAGE", FieldValue: "ja"})
+	actual, err := h.GetAcceptLanguages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 2 || actual[0].Range != "ja" || actual[1].Range != "en" {
+		t.Errorf("Unexpected result: %v.", actual)
+	}
+}
+
+func TestParseAcceptLanguage_Invalid(t *testing.T) {
+	for _, v := range []string{"invalid_range", "toolongsubtag", "en;q=high", "en ja"} {
+		if actual, err := ParseAcceptLanguage(v); err == nil {
+			t.Errorf("Unexpected result: %v for %v.", actual, v)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package header
 
 import (
 	"sort"
-	"strconv"
 	"strings"
 )
 
@@ -17,10 +16,11 @@ type AcceptParameter struct {
 	Value string
 }
 
-func (a Accept) getWeight() float64 {
+// Weight returns q parameter. It's 1 if q is not specified.
+func (a Accept) Weight() float64 {
 	for _, v := range a.AcceptParameters {
-		if v.Key == "q" {
-			q, err := strconv.ParseFloat(v.Value, 64)
+		if strings.EqualFold(v.Key, "q") {
+			q, err := ParseQValue(v.Value)
 			if err != nil {
 				return 1
 			}
@@ -30,35 +30,61 @@ func (a Accept) getWeight() float64 {
 	return 1
 }
 
-func ParseAccept(headerValue string) []Accept {
-
+// ParseAccept parses Accept (RFC 9110 12.5.1) and returns media ranges in order of weight.
+// Invalid media ranges are skipped and valid ones are returned with the first error.
+//
+//	Accept      = #( media-range [ weight ] )
+//	media-range = ( "*/*" / ( type "/*" ) / ( type "/" subtype ) ) parameters
+func ParseAccept(headerValue string) ([]Accept, error) {
 	accepts := []Accept{}
-
-	for _, val := range strings.Split(headerValue, ",") {
-		sp := strings.Split(val, ";")
-		typ := strings.Split(sp[0], "/")
-
-		mainType := strings.TrimSpace(typ[0])
-		subType := strings.TrimSpace(typ[1])
-
+	err := ParseListLenient(headerValue, func(l *Lexer) error {
+		mainType, err := l.Token()
+		if err != nil {
+			return err
+		}
+		if err := l.Expect('/'); err != nil {
+			return err
+		}
+		subType, err := l.Token()
+		if err != nil {
+			return err
+		}
+		if mainType == "*" && subType != "*" {
+			return l.Errorf("media range %v/%v is invalid", mainType, subType)
+		}
+		params, err := l.Parameters()
+		if err != nil {
+			return err
+		}
+		if _, err := Weight(params); err != nil {
+			return l.Errorf("weight of %v/%v is invalid", mainType, subType)
+		}
+		if err := l.EndOfElement(); err != nil {
+			return err
+		}
 		acceptParameters := []AcceptParameter{}
-		for _, v := range sp[1:] {
-			params := strings.Split(v, "=")
-			if len(params) == 1 {
-				acceptParameters = append(acceptParameters,
-					AcceptParameter{Value: strings.TrimSpace(params[0])})
-				continue
-			}
-			acceptParameters = append(acceptParameters,
-				AcceptParameter{Key: strings.TrimSpace(params[0]), Value: strings.TrimSpace(params[1])})
+		for _, p := range params {
+			acceptParameters = append(acceptParameters, AcceptParameter{Key: p.Name, Value: p.Value})
 		}
-		accepts = append(accepts, Accept{Type: mainType, SubType: subType,
-			AcceptParameters: acceptParameters})
-	}
+		accepts = append(accepts, Accept{Type: mainType, SubType: subType, AcceptParameters: acceptParameters})
+		return nil
+	})
 
-	sort.Slice(accepts, func(i, j int) bool {
-		return accepts[i].getWeight() > accepts[j].getWeight()
+	sort.SliceStable(accepts, func(i, j int) bool {
+		return accepts[i].Weight() > accepts[j].Weight()
 	})
 
-	return accepts
+	return accepts, err
+}
+
+// MediaParameters returns parameters before q. Parameters after q are not about media type.
+func (a Accept) MediaParameters() []AcceptParameter {
+	params := []AcceptParameter{}
+	for _, v := range a.AcceptParameters {
+		if strings.EqualFold(v.Key, "q") {
+			break
+		}
+		params = append(params, v)
+	}
+	return params
 }
@@ -3,7 +3,10 @@ package header
 import "testing"
 
 func TestParseAccept1(t *testing.T) {
-	actual := ParseAccept("audio/*; q=0.2, audio/basic")
+	actual, err := ParseAccept("audio/*; q=0.2, audio/basic")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	assertType(t, actual[0], "audio", "basic") // high priority
 	assertNoParameter(t, actual[0].AcceptParameters)
@@ -14,7 +17,10 @@ func TestParseAccept1(t *testing.T) {
 }
 
 func TestParseAccept2(t *testing.T) {
-	actual := ParseAccept("text/plain; q=0.5, text/html, text/x-dvi; q=0.8, text/x-c")
+	actual, err := ParseAccept("text/plain; q=0.5, text/html, text/x-dvi; q=0.8, text/x-c")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	assertType(t, actual[0], "text", "html")
 	assertNoParameter(t, actual[0].AcceptParameters)
@@ -60,3 +66,46 @@ func assertNoParameter(t *testing.T, params []AcceptParameter) {
 		t.Errorf("Unexpected parameter: %v.", params)
 	}
 }
+
+func TestParseAccept_Invalid(t *testing.T) {
+	for _, v := range []string{"text, application/json", "/html", "text/", "*/html", "text/html;q=2", "text/html;q=0.1234", "text/html;level"} {
+		if actual, err := ParseAccept(v); err == nil {
+			t.Errorf("Unexpected result: %v for %v.", actual, v)
+		}
+	}
+}
+
+func TestParseAccept_SkipInvalid(t *testing.T) {
+	actual, err := ParseAccept(`text, application/json;q=0.5, text/html;q=2, text/plain;a="b`)
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("Unexpected error: %v.", err)
+	}
+	if len(actual) != 1 {
+		t.Fatalf("Unexpected result: %v.", actual)
+	}
+	assertType(t, actual[0], "application", "json")
+}
+
+func TestParseAccept_QuotedParameter(t *testing.T) {
+	actual, err := ParseAccept(`text/html;foo="a, b;c", application/json;q=0.5`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 2 {
+		t.Fatalf("Unexpected result: %v.", actual)
+	}
+	assertType(t, actual[0], "text", "html")
+	assertParameter(t, actual[0].AcceptParameters[0], "foo", "a, b;c")
+	assertType(t, actual[1], "application", "json")
+}
+
+func TestParseAccept_EmptyElements(t *testing.T) {
+	actual, err := ParseAccept(" , text/html, ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 1 {
+		t.Fatalf("Unexpected result: %v.", actual)
+	}
+	assertType(t, actual[0], "text", "html")
+}
@@ -1,24 +1,28 @@
 package header
 
-type ContentCoding int
+import "strings"
+
+// ContentCoding is lowercased coding name like "gzip".
+// Codings not listed here can be registered to coding package.
+type ContentCoding string
 
 const (
-	CONTENT_CODING_COMPRESS ContentCoding = iota
-	CONTENT_CODING_DEFLATE
-	CONTENT_CODING_GZIP
-	CONTENT_CODING_IDENTITY
+	CONTENT_CODING_COMPRESS ContentCoding = "compress"
+	CONTENT_CODING_DEFLATE  ContentCoding = "deflate"
+	CONTENT_CODING_GZIP     ContentCoding = "gzip"
+	CONTENT_CODING_IDENTITY ContentCoding = "identity"
 )
 
 func getContentCoding(v string) ContentCoding {
-	switch v {
-	case "compress":
-		return CONTENT_CODING_COMPRESS
-	case "deflate":
-		return CONTENT_CODING_DEFLATE
-	case "identity":
-		return CONTENT_CODING_IDENTITY
-	case "gzip":
+	switch v = strings.ToLower(v); v {
+	case "x-gzip":
 		return CONTENT_CODING_GZIP
+	case "x-compress":
+		return CONTENT_CODING_COMPRESS
 	}
-	return 0 // TODO
+	return ContentCoding(v)
+}
+
+func (c ContentCoding) ToString() string {
+	return string(c)
 }
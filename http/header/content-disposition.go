@@ -0,0 +1,61 @@
+package header
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContentDisposition is Content-Disposition (RFC 6266). It's used by parts of multipart/form-data (RFC 7578 4.2).
+//
+//	content-disposition = disposition-type *( OWS ";" OWS disposition-parm )
+type ContentDisposition struct {
+	// Type is lowercased disposition-type like "form-data".
+	Type string
+	// Parameters have lowercased names and unquoted values in order of appearance.
+	Parameters []Parameter
+}
+
+// ParseContentDisposition parses disposition type with parameters.
+func ParseContentDisposition(value string) (ContentDisposition, error) {
+	l := NewLexer(strings.TrimSpace(value))
+	typ, err := l.Token()
+	if err != nil {
+		return ContentDisposition{}, err
+	}
+	params, err := l.Parameters()
+	if err != nil {
+		return ContentDisposition{}, err
+	}
+	if l.SkipOWS(); !l.EOF() {
+		return ContentDisposition{}, l.Errorf("';' is expected")
+	}
+	return ContentDisposition{Type: strings.ToLower(typ), Parameters: params}, nil
+}
+
+// Parameter returns value of parameter. name is case-insensitive.
+func (c ContentDisposition) Parameter(name string) (string, bool) {
+	for _, p := range c.Parameters {
+		if strings.EqualFold(p.Name, name) {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ToString returns field value of Content-Disposition. Parameter values are quoted if necessary.
+func (c ContentDisposition) ToString() string {
+	s := c.Type
+	for _, p := range c.Parameters {
+		s += fmt.Sprintf("; %v=%v", p.Name, QuoteString(p.Value))
+	}
+	return s
+}
+
+// GetContentDisposition returns parsed Content-Disposition. ok is false if it doesn't exist.
+func (h Headers) GetContentDisposition() (cd ContentDisposition, ok bool, err error) {
+	if !h.Has("CONTENT-DISPOSITION") {
+		return ContentDisposition{}, false, nil
+	}
+	cd, err = ParseContentDisposition(h.Get("CONTENT-DISPOSITION"))
+	return cd, err == nil, err
+}
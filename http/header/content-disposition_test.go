@@ -0,0 +1,30 @@
+package header
+
+import "testing"
+
+func TestParseContentDisposition(t *testing.T) {
+	actual, err := ParseContentDisposition(`Form-Data; Name="field 1"; filename=a.txt`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v.", err)
+	}
+	if actual.Type != "form-data" {
+		t.Errorf("Unexpected type: %v.", actual.Type)
+	}
+	if v, _ := actual.Parameter("name"); v != "field 1" {
+		t.Errorf("Unexpected name: %v.", v)
+	}
+	if v, _ := actual.Parameter("FILENAME"); v != "a.txt" {
+		t.Errorf("Unexpected filename: %v.", v)
+	}
+	if actual.ToString() != `form-data; name="field 1"; filename=a.txt` {
+		t.Errorf("Unexpected string: %v.", actual.ToString())
+	}
+}
+
+func TestParseContentDisposition_Invalid(t *testing.T) {
+	for _, v := range []string{"", "form-data; name", `form-data; name="a`, "form-data name=a", "form/data"} {
+		if _, err := ParseContentDisposition(v); err == nil {
+			t.Errorf("Unexpected success: %v.", v)
+		}
+	}
+}
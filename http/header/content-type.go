@@ -1,6 +1,67 @@
 package header
 
+import (
+	"fmt"
+	"strings"
+)
+
+// ContentType is media type of Content-Type (RFC 9110 8.3.1).
+//
+//	media-type = type "/" subtype parameters
+//	parameters = *( OWS ";" OWS [ parameter ] )
+//	parameter  = parameter-name "=" parameter-value
 type ContentType struct {
-	contentType string
-	subtype     string
+	// Type and Subtype are lowercased.
+	Type    string
+	Subtype string
+	// Parameters have lowercased names and unquoted values in order of appearance.
+	Parameters []Parameter
+}
+
+// ParseContentType parses media type with parameters.
+func ParseContentType(value string) (ContentType, error) {
+	l := NewLexer(strings.TrimSpace(value))
+	typ, err := l.Token()
+	if err != nil {
+		return ContentType{}, err
+	}
+	if err := l.Expect('/'); err != nil {
+		return ContentType{}, err
+	}
+	subtype, err := l.Token()
+	if err != nil {
+		return ContentType{}, err
+	}
+	params, err := l.Parameters()
+	if err != nil {
+		return ContentType{}, err
+	}
+	if l.SkipOWS(); !l.EOF() {
+		return ContentType{}, l.Errorf("';' is expected")
+	}
+	return ContentType{Type: strings.ToLower(typ), Subtype: strings.ToLower(subtype), Parameters: params}, nil
+}
+
+// MediaType returns type/subtype without parameters.
+func (c ContentType) MediaType() string {
+	return c.Type + "/" + c.Subtype
+}
+
+// Parameter returns value of parameter. name is case-insensitive.
+func (c ContentType) Parameter(name string) (string, bool) {
+	for _, p := range c.Parameters {
+		if strings.EqualFold(p.Name, name) {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ToString returns field value of Content-Type. Parameter values are quoted if necessary.
+func (c ContentType) ToString() string {
+	s := c.MediaType()
+	for _, p := range c.Parameters {
+		s += fmt.Sprintf("; %v=%v", p.Name, QuoteString(p.Value))
+	}
+	return s
 }
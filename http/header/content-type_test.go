@@ -0,0 +1,40 @@
+package header
+
+import "testing"
+
+func TestParseContentType(t *testing.T) {
+	actual, err := ParseContentType(`Multipart/Form-Data; Boundary="a b\"c"; charset=UTF-8 ;;`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v.", err)
+	}
+	if actual.MediaType() != "multipart/form-data" {
+		t.Errorf("Unexpected media type: %v.", actual.MediaType())
+	}
+	if v, _ := actual.Parameter("boundary"); v != `a b"c` {
+		t.Errorf("Unexpected boundary: %v.", v)
+	}
+	if v, _ := actual.Parameter("CHARSET"); v != "UTF-8" {
+		t.Errorf("Unexpected charset: %v.", v)
+	}
+	if _, ok := actual.Parameter("q"); ok {
+		t.Errorf("Unexpected parameter: %v.", actual.Parameters)
+	}
+	if actual.ToString() != `multipart/form-data; boundary="a b\"c"; charset=UTF-8` {
+		t.Errorf("Unexpected string: %v.", actual.ToString())
+	}
+}
+
+func TestParseContentType_Invalid(t *testing.T) {
+	for _, v := range []string{"", "text", "text/", "/html", "text/html;charset", "text/html; charset=", `text/html; charset="utf-8`, "text/html charset=utf-8", "text/html; a=b c"} {
+		if _, err := ParseContentType(v); err == nil {
+			t.Errorf("Unexpected success: %v.", v)
+		}
+	}
+}
+
+func TestGetContentType_Default(t *testing.T) {
+	actual, err := Headers{}.GetContentType()
+	if err != nil || actual.MediaType() != "application/octet-stream" {
+		t.Errorf("Unexpected content type: %v %v.", actual, err)
+	}
+}
@@ -0,0 +1,229 @@
+package header
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCookie is returned for SetCookie which can't be sent.
+var ErrInvalidCookie = errors.New("header: invalid cookie")
+
+// Cookie is a cookie-pair of Cookie header (RFC 6265 4.2.1).
+type Cookie struct {
+	Name string
+	// Value is cookie-value without surrounding DQUOTEs.
+	Value string
+}
+
+func (c Cookie) ToString() string {
+	return c.Name + "=" + c.Value
+}
+
+// ParseCookie parses Cookie header.
+//
+//	cookie-string = cookie-pair *( ";" SP cookie-pair )
+//
+// It tolerates output of real browsers like missing SP, empty pairs and values
+// having SP, "," or "\". Pairs having invalid name or control characters are ignored.
+func ParseCookie(value string) []Cookie {
+	cookies := []Cookie{}
+	for _, pair := range strings.Split(value, ";") {
+		pair = strings.Trim(pair, " \t")
+		i := strings.IndexByte(pair, '=')
+		if i == -1 {
+			continue
+		}
+		name, v := strings.Trim(pair[:i], " \t"), strings.Trim(pair[i+1:], " \t")
+		if !IsToken(name) || !isLenientCookieValue(v) {
+			continue
+		}
+		if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+			v = v[1 : len(v)-1]
+		}
+		cookies = append(cookies, Cookie{Name: name, Value: v})
+	}
+	return cookies
+}
+
+// GetCookies returns cookies of all Cookie headers in order of appearance.
+// Cookie headers are not combined by ", " since it's not a list (RFC 6265 5.4).
+func (h Headers) GetCookies() []Cookie {
+	cookies := []Cookie{}
+	for _, v := range h.Values("COOKIE") {
+		cookies = append(cookies, ParseCookie(v)...)
+	}
+	return cookies
+}
+
+func isLenientCookieValue(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+type SameSite int
+
+const (
+	// SAME_SITE_DEFAULT omits SameSite attribute.
+	SAME_SITE_DEFAULT SameSite = iota
+	SAME_SITE_LAX
+	SAME_SITE_STRICT
+	SAME_SITE_NONE
+)
+
+func (s SameSite) ToString() string {
+	switch s {
+	case SAME_SITE_LAX:
+		return "Lax"
+	case SAME_SITE_STRICT:
+		return "Strict"
+	case SAME_SITE_NONE:
+		return "None"
+	}
+	return ""
+}
+
+// SetCookie is a cookie sent by Set-Cookie header (RFC 6265 4.1).
+type SetCookie struct {
+	Name  string
+	Value string
+	// Expires is omitted if it's zero.
+	Expires time.Time
+	// MaxAge is omitted if it's 0. Negative value is sent as Max-Age=0 to delete the cookie.
+	MaxAge      int
+	Domain      string
+	Path        string
+	Secure      bool
+	HttpOnly    bool
+	SameSite    SameSite
+	Partitioned bool
+}
+
+// Validate returns error wrapping ErrInvalidCookie if c can't be sent.
+func (c SetCookie) Validate() error {
+	if !IsToken(c.Name) {
+		return fmt.Errorf("%w: name %q", ErrInvalidCookie, c.Name)
+	}
+	if !isCookieValue(c.Value) {
+		return fmt.Errorf("%w: value %q of %v", ErrInvalidCookie, c.Value, c.Name)
+	}
+	if c.Domain != "" && !isCookieDomain(c.Domain) {
+		return fmt.Errorf("%w: domain %q of %v", ErrInvalidCookie, c.Domain, c.Name)
+	}
+	if c.Path != "" && !isAttributeValue(c.Path) {
+		return fmt.Errorf("%w: path %q of %v", ErrInvalidCookie, c.Path, c.Name)
+	}
+	if !c.Expires.IsZero() && c.Expires.Year() < 1601 {
+		// cookie-date before 1601 is rejected by user agents (RFC 6265 5.1.1)
+		return fmt.Errorf("%w: expires %v of %v", ErrInvalidCookie, c.Expires, c.Name)
+	}
+	if c.SameSite < SAME_SITE_DEFAULT || c.SameSite > SAME_SITE_NONE {
+		return fmt.Errorf("%w: SameSite %v of %v", ErrInvalidCookie, int(c.SameSite), c.Name)
+	}
+	// user agents reject these cookies without Secure
+	if !c.Secure && (c.SameSite == SAME_SITE_NONE || c.Partitioned || strings.HasPrefix(c.Name, "__Secure-") || strings.HasPrefix(c.Name, "__Host-")) {
+		return fmt.Errorf("%w: %v requires Secure", ErrInvalidCookie, c.Name)
+	}
+	if strings.HasPrefix(c.Name, "__Host-") && (c.Domain != "" || c.Path != "/") {
+		return fmt.Errorf("%w: %v requires Path=/ without Domain", ErrInvalidCookie, c.Name)
+	}
+	return nil
+}
+
+// ToString returns field value of Set-Cookie. c should be validated by Validate.
+func (c SetCookie) ToString() string {
+	s := c.Name + "=" + c.Value
+	if !c.Expires.IsZero() {
+		s += "; Expires=" + c.Expires.UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT")
+	}
+	if c.MaxAge > 0 {
+		s += "; Max-Age=" + strconv.Itoa(c.MaxAge)
+	} else if c.MaxAge < 0 {
+		s += "; Max-Age=0"
+	}
+	if c.Domain != "" {
+		s += "; Domain=" + c.Domain
+	}
+	if c.Path != "" {
+		s += "; Path=" + c.Path
+	}
+	if c.Secure {
+		s += "; Secure"
+	}
+	if c.HttpOnly {
+		s += "; HttpOnly"
+	}
+	if c.SameSite != SAME_SITE_DEFAULT {
+		s += "; SameSite=" + c.SameSite.ToString()
+	}
+	if c.Partitioned {
+		s += "; Partitioned"
+	}
+	return s
+}
+
+// AddSetCookie validates c and appends Set-Cookie header.
+// Each cookie has its own field line since Set-Cookie can't be combined (RFC 9110 5.3).
+func (h *Headers) AddSetCookie(c SetCookie) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	h.Add("Set-Cookie", c.ToString())
+	return nil
+}
+
+// cookie-value = *cookie-octet / ( DQUOTE *cookie-octet DQUOTE )
+func isCookieValue(s string) bool {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	for i := 0; i < len(s); i++ {
+		if !isCookieOctet(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// cookie-octet = %x21 / %x23-2B / %x2D-3A / %x3C-5B / %x5D-7E
+// US-ASCII characters excluding CTLs, whitespace, DQUOTE, comma, semicolon and backslash.
+func isCookieOctet(c byte) bool {
+	return 0x21 <= c && c <= 0x7e && c != '"' && c != ',' && c != ';' && c != '\\'
+}
+
+// isCookieDomain returns true if s is a domain like example.com. Leading "." is allowed
+// and ignored by user agents (RFC 6265 5.2.3).
+func isCookieDomain(s string) bool {
+	s = strings.TrimPrefix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// av-octet = any CHAR except CTLs or ";"
+func isAttributeValue(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] >= 0x7f || s[i] == ';' {
+			return false
+		}
+	}
+	return true
+}
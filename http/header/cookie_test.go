@@ -0,0 +1,103 @@
+package header
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCookie(t *testing.T) {
+	tests := map[string][]Cookie{
+		"a=1; b=2":                {{"a", "1"}, {"b", "2"}},
+		"a=1;b=2;":                {{"a", "1"}, {"b", "2"}},
+		` a = "quoted" ;; c=`:     {{"a", "quoted"}, {"c", ""}},
+		`a=x y,z\; b=k=v`:         {{"a", "x y,z\\"}, {"b", "k=v"}},
+		"novalue; a b=1; =2; c=3": {{"c", "3"}},
+		"a=\x01; b=\xe3\x81\x82":  {{"b", "\xe3\x81\x82"}},
+		"":                        {},
+	}
+	for value, expected := range tests {
+		actual := ParseCookie(value)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Unexpected cookies: %v for %q.", actual, value)
+		}
+	}
+}
+
+func TestGetCookies(t *testing.T) {
+	h := NewHeaders(Header{"Cookie", "a=1; b=2"}, Header{"Host", "example.com"}, Header{"cookie", "c=3"})
+	expected := []Cookie{{"a", "1"}, {"b", "2"}, {"c", "3"}}
+	if actual := h.GetCookies(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected cookies: %v.", actual)
+	}
+}
+
+func TestSetCookie_ToString(t *testing.T) {
+	c := SetCookie{Name: "id", Value: "a3fWa", Expires: time.Date(2015, 10, 21, 16, 28, 0, 0, time.FixedZone("JST", 9*60*60)),
+		MaxAge: 3600, Domain: "example.com", Path: "/docs", Secure: true, HttpOnly: true, SameSite: SAME_SITE_NONE, Partitioned: true}
+	expected := "id=a3fWa; Expires=Wed, 21 Oct 2015 07:28:00 GMT; Max-Age=3600; Domain=example.com; Path=/docs; Secure; HttpOnly; SameSite=None; Partitioned"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v.", err)
+	}
+	if c.ToString() != expected {
+		t.Errorf("Unexpected string: %v.", c.ToString())
+	}
+
+	deleted := SetCookie{Name: "id", MaxAge: -1}
+	if deleted.ToString() != "id=; Max-Age=0" {
+		t.Errorf("Unexpected string: %v.", deleted.ToString())
+	}
+}
+
+func TestSetCookie_Invalid(t *testing.T) {
+	tests := []SetCookie{
+		{Name: ""},
+		{Name: "a b", Value: "1"},
+		{Name: "a", Value: "x y"},
+		{Name: "a", Value: "x;y"},
+		{Name: "a", Value: `"x`},
+		{Name: "a", Value: "\xe3\x81\x82"},
+		{Name: "a", Domain: "example..com"},
+		{Name: "a", Domain: "-example.com"},
+		{Name: "a", Path: "/a;b"},
+		{Name: "a", Expires: time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "a", SameSite: SameSite(10)},
+		{Name: "a", SameSite: SAME_SITE_NONE},
+		{Name: "a", Partitioned: true},
+		{Name: "__Secure-a"},
+		{Name: "__Host-a", Secure: true, Path: "/a"},
+		{Name: "__Host-a", Secure: true, Path: "/", Domain: "example.com"},
+	}
+	for _, c := range tests {
+		if err := c.Validate(); !errors.Is(err, ErrInvalidCookie) {
+			t.Errorf("Unexpected error: %v for %#v.", err, c)
+		}
+	}
+	valid := []SetCookie{
+		{Name: "a", Value: `"quoted"`},
+		{Name: "a", Domain: ".example.com"},
+		{Name: "__Host-a", Secure: true, Path: "/"},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("Unexpected error: %v for %#v.", err, c)
+		}
+	}
+}
+
+func TestAddSetCookie(t *testing.T) {
+	h := Headers{}
+	if err := h.AddSetCookie(SetCookie{Name: "a", Value: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AddSetCookie(SetCookie{Name: "b", Value: "2", HttpOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AddSetCookie(SetCookie{Name: "c", Value: "x y"}); err == nil {
+		t.Errorf("Unexpected success.")
+	}
+	if values := h.Values("Set-Cookie"); !reflect.DeepEqual(values, []string{"a=1", "b=2; HttpOnly"}) {
+		t.Errorf("Unexpected Set-Cookie: %v.", values)
+	}
+}
@@ -0,0 +1,259 @@
+package header
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseError is returned for malformed field value.
+type ParseError struct {
+	// Value is the whole field value.
+	Value string
+	// Offset is position in Value where parsing failed.
+	Offset int
+	Msg    string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%v at offset %v of %q.", err.Msg, err.Offset, err.Value)
+}
+
+// Parameter is name and value pair like charset=utf-8.
+type Parameter struct {
+	Name  string
+	Value string
+}
+
+// Lexer reads field value by grammar of RFC 9110 5.6.
+type Lexer struct {
+	value string
+	pos   int
+}
+
+func NewLexer(value string) *Lexer {
+	return &Lexer{value: value}
+}
+
+// EOF returns true if whole value is read.
+func (l *Lexer) EOF() bool {
+	return l.pos == len(l.value)
+}
+
+// Peek returns next byte without reading it. It returns 0 at EOF.
+func (l *Lexer) Peek() byte {
+	if l.EOF() {
+		return 0
+	}
+	return l.value[l.pos]
+}
+
+// Consume reads c if it's next.
+func (l *Lexer) Consume(c byte) bool {
+	if l.EOF() || l.value[l.pos] != c {
+		return false
+	}
+	l.pos++
+	return true
+}
+
+// Expect reads c or returns error.
+func (l *Lexer) Expect(c byte) error {
+	if !l.Consume(c) {
+		return l.Errorf("'%c' is expected", c)
+	}
+	return nil
+}
+
+// SkipOWS skips OWS = *( SP / HTAB ).
+func (l *Lexer) SkipOWS() {
+	for !l.EOF() && (l.value[l.pos] == ' ' || l.value[l.pos] == '\t') {
+		l.pos++
+	}
+}
+
+// Token reads token = 1*tchar.
+func (l *Lexer) Token() (string, error) {
+	token, n := ReadToken(l.value[l.pos:])
+	if n == 0 {
+		return "", l.Errorf("token is expected")
+	}
+	l.pos += n
+	return token, nil
+}
+
+// QuotedString reads quoted-string and returns unescaped value.
+func (l *Lexer) QuotedString() (string, error) {
+	v, n, ok := ReadQuotedString(l.value[l.pos:])
+	if !ok {
+		return "", l.Errorf("quoted-string is invalid")
+	}
+	l.pos += n
+	return v, nil
+}
+
+// TokenOrQuotedString reads token or quoted-string.
+func (l *Lexer) TokenOrQuotedString() (string, error) {
+	if l.Peek() == '"' {
+		return l.QuotedString()
+	}
+	return l.Token()
+}
+
+// Digits reads 1*DIGIT.
+func (l *Lexer) Digits() (string, error) {
+	start := l.pos
+	for !l.EOF() && '0' <= l.value[l.pos] && l.value[l.pos] <= '9' {
+		l.pos++
+	}
+	if start == l.pos {
+		return "", l.Errorf("digit is expected")
+	}
+	return l.value[start:l.pos], nil
+}
+
+// Parameters reads parameters = *( OWS ";" OWS [ parameter ] ).
+// parameter = parameter-name "=" ( token / quoted-string ). Names are lowercased.
+func (l *Lexer) Parameters() ([]Parameter, error) {
+	params := []Parameter{}
+	for {
+		pos := l.pos
+		l.SkipOWS()
+		if !l.Consume(';') {
+			l.pos = pos
+			return params, nil
+		}
+		l.SkipOWS()
+		if c := l.Peek(); l.EOF() || c == ';' || c == ',' {
+			continue
+		}
+		name, err := l.Token()
+		if err != nil {
+			return nil, err
+		}
+		if err := l.Expect('='); err != nil {
+			return nil, err
+		}
+		value, err := l.TokenOrQuotedString()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, Parameter{Name: strings.ToLower(name), Value: value})
+	}
+}
+
+// Errorf returns ParseError at current position.
+func (l *Lexer) Errorf(format string, args ...interface{}) *ParseError {
+	return &ParseError{Value: l.value, Offset: l.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// ParseList parses #element (RFC 9110 5.6.1). Empty elements are ignored.
+// element reads an element from l.
+func ParseList(value string, element func(l *Lexer) error) error {
+	return NewLexer(value).List(element)
+}
+
+// List reads #element until EOF. Empty elements are ignored.
+func (l *Lexer) List(element func(l *Lexer) error) error {
+	for {
+		l.SkipOWS()
+		if l.EOF() {
+			return nil
+		}
+		if l.Consume(',') {
+			continue
+		}
+		if err := element(l); err != nil {
+			return err
+		}
+		l.SkipOWS()
+		if !l.EOF() && !l.Consume(',') {
+			return l.Errorf("',' is expected")
+		}
+	}
+}
+
+// ParseListLenient parses #element like ParseList but invalid elements are skipped.
+// It returns the first error after reading whole value, so valid elements are kept even if it's not nil.
+// element should keep its result only after EndOfElement succeeds.
+func ParseListLenient(value string, element func(l *Lexer) error) error {
+	return NewLexer(value).ListLenient(element)
+}
+
+// ListLenient reads #element until EOF skipping invalid elements. It returns the first error.
+func (l *Lexer) ListLenient(element func(l *Lexer) error) error {
+	var first error
+	for {
+		l.SkipOWS()
+		if l.EOF() {
+			return first
+		}
+		if l.Consume(',') {
+			continue
+		}
+		start := l.pos
+		if err := element(l); err != nil {
+			if first == nil {
+				first = err
+			}
+			// element is skipped from its start since it may fail in quoted-string
+			l.pos = start
+			l.skipElement()
+			continue
+		}
+		l.SkipOWS()
+		l.Consume(',')
+	}
+}
+
+// EndOfElement returns error if element is not followed by "," or EOF. It doesn't read ",".
+func (l *Lexer) EndOfElement() error {
+	pos := l.pos
+	l.SkipOWS()
+	end := l.EOF() || l.Peek() == ','
+	l.pos = pos
+	if !end {
+		return l.Errorf("',' is expected")
+	}
+	return nil
+}
+
+// skipElement reads until "," which is not in quoted-string or EOF.
+func (l *Lexer) skipElement() {
+	quoted := false
+	for ; !l.EOF(); l.pos++ {
+		switch c := l.value[l.pos]; {
+		case quoted && c == '\\' && l.pos+1 < len(l.value):
+			l.pos++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == ',':
+			return
+		}
+	}
+}
+
+// ParseQValue parses qvalue = ( "0" [ "." 0*3DIGIT ] ) / ( "1" [ "." 0*3("0") ] ).
+func ParseQValue(s string) (float64, error) {
+	valid := len(s) != 0 && len(s) <= 5 && (s[0] == '0' || s[0] == '1')
+	if valid && len(s) > 1 {
+		valid = s[1] == '.'
+		for i := 2; i < len(s); i++ {
+			valid = valid && '0' <= s[i] && s[i] <= '9' && (s[0] == '0' || s[i] == '0')
+		}
+	}
+	if !valid {
+		return 0, &ParseError{Value: s, Msg: "qvalue is invalid"}
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Weight returns q of params (RFC 9110 12.4.2). It's 1 if q doesn't exist.
+func Weight(params []Parameter) (float64, error) {
+	for _, p := range params {
+		if p.Name == "q" {
+			return ParseQValue(p.Value)
+		}
+	}
+	return 1, nil
+}
@@ -0,0 +1,137 @@
+package header
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	actual := []string{}
+	err := ParseList(` a, ,b;x="1,2" ,, c `, func(l *Lexer) error {
+		token, err := l.Token()
+		if err != nil {
+			return err
+		}
+		if _, err := l.Parameters(); err != nil {
+			return err
+		}
+		actual = append(actual, token)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 3 || actual[0] != "a" || actual[1] != "b" || actual[2] != "c" {
+		t.Errorf("Unexpected elements: %v.", actual)
+	}
+}
+
+func TestParseList_Invalid(t *testing.T) {
+	err := ParseList("a b", func(l *Lexer) error {
+		_, err := l.Token()
+		return err
+	})
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Unexpected error: %v.", err)
+	}
+	if perr.Offset != 2 {
+		t.Errorf("Unexpected offset: %v.", perr.Offset)
+	}
+}
+
+func TestParseListLenient(t *testing.T) {
+	actual := []string{}
+	err := ParseListLenient(`a, b c, "x,y" d, e;p="1,2", f`, func(l *Lexer) error {
+		v, err := l.Token()
+		if err != nil {
+			return err
+		}
+		if _, err := l.Parameters(); err != nil {
+			return err
+		}
+		if err := l.EndOfElement(); err != nil {
+			return err
+		}
+		actual = append(actual, v)
+		return nil
+	})
+	if strings.Join(actual, ",") != "a,e,f" {
+		t.Errorf("Unexpected elements: %v.", actual)
+	}
+	perr, ok := err.(*ParseError)
+	if !ok || perr.Offset != 4 {
+		t.Errorf("Unexpected error: %v.", err)
+	}
+}
+
+func TestLexer_QuotedString(t *testing.T) {
+	l := NewLexer(`"a\"b\\c, d"rest`)
+	actual, err := l.QuotedString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != `a"b\c, d` {
+		t.Errorf("Unexpected value: %v.", actual)
+	}
+	if token, _ := l.Token(); token != "rest" {
+		t.Errorf("Unexpected rest: %v.", token)
+	}
+
+	if _, err := NewLexer(`"unterminated`).QuotedString(); err == nil {
+		t.Errorf("Unterminated quoted-string is accepted.")
+	}
+}
+
+func TestLexer_Parameters(t *testing.T) {
+	l := NewLexer(`; A=1 ;; b="x;y" ;`)
+	actual, err := l.Parameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Parameter{{"a", "1"}, {"b", "x;y"}}
+	if len(actual) != len(expected) {
+		t.Fatalf("Unexpected parameters: %v.", actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Unexpected parameter: %v.", actual[i])
+		}
+	}
+	if !l.EOF() {
+		t.Errorf("Parameters are not read to the end.")
+	}
+
+	for _, v := range []string{";a", ";a=", ";=1", `;a="1`} {
+		if _, err := NewLexer(v).Parameters(); err == nil {
+			t.Errorf("Invalid parameters %v are accepted.", v)
+		}
+	}
+}
+
+func TestParseQValue(t *testing.T) {
+	valid := map[string]float64{"0": 0, "0.": 0, "0.5": 0.5, "0.123": 0.123, "1": 1, "1.0": 1, "1.000": 1}
+	for v, expected := range valid {
+		actual, err := ParseQValue(v)
+		if err != nil || actual != expected {
+			t.Errorf("Unexpected qvalue: %v, %v for %v.", actual, err, v)
+		}
+	}
+	for _, v := range []string{"", ".5", "0.1234", "1.001", "2", "-1", "0,5", "1e0"} {
+		if _, err := ParseQValue(v); err == nil {
+			t.Errorf("Invalid qvalue %v is accepted.", v)
+		}
+	}
+}
+
+func TestWeight(t *testing.T) {
+	if w, err := Weight([]Parameter{{"level", "1"}}); err != nil || w != 1 {
+		t.Errorf("Unexpected weight: %v, %v.", w, err)
+	}
+	if w, err := Weight([]Parameter{{"q", "0.3"}}); err != nil || w != 0.3 {
+		t.Errorf("Unexpected weight: %v, %v.", w, err)
+	}
+	if _, err := Weight([]Parameter{{"q", "high"}}); err == nil {
+		t.Errorf("Invalid weight is accepted.")
+	}
+}
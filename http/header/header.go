@@ -7,6 +7,7 @@ import (
 	"github.com/inabajunmr/http11server/http"
 )
 
+// Header is a field line. FieldName keeps its case as received.
 type Header struct {
 	FieldName  string
 	FieldValue string
@@ -29,27 +30,34 @@ func ParseHeader(line string) (*Header, error) {
 	}
 
 	if !validateFieldName(l[0]) {
-		return nil, &HeaderParserError{Msg: fmt.Sprintf("Header line:%v don't has ':'.", line)}
+		return nil, &HeaderParserError{Msg: fmt.Sprintf("Header line:%v has invalid field name.", line)}
 	}
-	if len(l[1]) == 0 {
-		return nil, &HeaderParserError{Msg: fmt.Sprintf("Header line:%v don't has ':'.", line)}
+	if !validateFieldValue(strings.TrimSpace(l[1])) {
+		return nil, &HeaderParserError{Msg: fmt.Sprintf("Header line:%v has invalid field value.", line)}
 	}
 
-	return &Header{strings.ToUpper(l[0]), strings.TrimSpace(l[1])}, nil
+	return &Header{l[0], strings.TrimSpace(l[1])}, nil
 
 }
 
+// field-name = token
 func validateFieldName(fieldName string) bool {
 	if len(fieldName) == 0 {
 		return false
 	}
-	return isVisibleASCII(fieldName) // l[0] never conains ':'
-
+	for i := 0; i < len(fieldName); i++ {
+		if !IsTokenChar(fieldName[i]) {
+			return false
+		}
+	}
+	return true
 }
 
-func isVisibleASCII(s string) bool {
-	for _, c := range s {
-		if c < '\u0021' || c > '\u007E' {
+// field-value = *( VCHAR / obs-text / SP / HTAB ). It can be empty.
+func validateFieldValue(fieldValue string) bool {
+	for i := 0; i < len(fieldValue); i++ {
+		c := fieldValue[i]
+		if c != ' ' && c != '\t' && (c < 0x21 || c == 0x7F) {
 			return false
 		}
 	}
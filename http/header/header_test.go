@@ -18,12 +18,12 @@ func TestParseHeader(t *testing.T) {
 		{
 			name: "Location",
 			args: "Location: example.com",
-			want: expected{fieldName: "LOCATION", fieldValue: "example.com"},
+			want: expected{fieldName: "Location", fieldValue: "example.com"},
 		},
 		{
 			name: "Content-Type",
 			args: "Content-Type: text/html; charset=utf-8",
-			want: expected{fieldName: "CONTENT-TYPE", fieldValue: "text/html; charset=utf-8"},
+			want: expected{fieldName: "Content-Type", fieldValue: "text/html; charset=utf-8"},
 		},
 	}
 
@@ -49,7 +49,7 @@ func TestValidate_NoHostHeader(t *testing.T) {
 	if err != nil {
 		t.Errorf("Unexpected error: %v.", err)
 	}
-	hs := Headers{h}
+	hs := NewHeaders(*h)
 	httpError := hs.Validate()
 	if httpError == nil {
 		t.Error("Unexpected success")
@@ -68,7 +68,7 @@ func TestValidate_MultipleHostHeader(t *testing.T) {
 	if err != nil {
 		t.Errorf("Unexpected error: %v.", err)
 	}
-	hs := Headers{h1, h2}
+	hs := NewHeaders(*h1, *h2)
 	httpError := hs.Validate()
 	if httpError == nil {
 		t.Error("Unexpected success")
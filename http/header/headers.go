@@ -8,148 +8,280 @@ import (
 	"github.com/inabajunmr/http11server/http"
 )
 
-type Headers []*Header
+// Headers is header fields in order of appearance.
+// Field names keep their original case and are looked up case-insensitively through an index.
+// Zero value is empty Headers. Its state is allocated at first modification.
+// Copies of non-empty Headers share fields and index, so modification of a copy is visible
+// from others. Clone must be used to modify a copy independently.
+type Headers struct {
+	*fieldState
+}
+
+type fieldState struct {
+	fields []Header
+	// index maps lowercased field name to positions in fields.
+	index map[string][]int
+}
+
+// NewHeaders returns Headers having fields in order.
+func NewHeaders(fields ...Header) Headers {
+	h := Headers{}
+	for _, f := range fields {
+		h.Add(f.FieldName, f.FieldValue)
+	}
+	return h
+}
 
 func (hs Headers) ToString() string {
 	v := ""
-	for _, h := range hs {
+	for _, h := range hs.list() {
 		v += h.ToString() + "\n"
 	}
 	return v
 }
 
-func (h Headers) Validate() error {
-	if len(h.filter("HOST")) != 1 {
-		return &http.HTTPError{Status: 400, Msg: "Request require only one Host header."}
-	}
-	exp := h.filter("EXPECT")
-	if len(exp) != 0 && strings.ToUpper(exp[0].FieldValue) != "100-CONTINUE" {
-		return &http.HTTPError{Status: 417, Msg: "Expectation Failed"}
-	}
-	return nil
+// Len returns number of field lines.
+func (h Headers) Len() int {
+	return len(h.list())
 }
 
-func (h Headers) GetAccept() []Accept {
-	filtered := h.filter("ACCEPT")
-	if len(filtered) == 0 {
-		return []Accept{}
-	}
-	return ParseAccept(filtered[0].FieldValue)
+// Fields returns copy of all field lines in order of appearance with original names.
+func (h Headers) Fields() []Header {
+	return append([]Header{}, h.list()...)
 }
 
-func (h Headers) IsConnectionClose() bool {
-	filtered := h.filter("CONNECTION")
-	if len(filtered) == 0 {
-		return false
+// Clone returns Headers not sharing fields with h.
+func (h Headers) Clone() Headers {
+	return NewHeaders(h.list()...)
+}
+
+// Has returns true if name exists. name is case-insensitive.
+func (h Headers) Has(name string) bool {
+	return len(h.positions(name)) != 0
+}
+
+// Get returns first value of name. name is case-insensitive.
+func (h Headers) Get(name string) string {
+	positions := h.positions(name)
+	if len(positions) == 0 {
+		return ""
 	}
-	return filtered[0].FieldValue == "close"
+	return h.fields[positions[0]].FieldValue
 }
 
-func (h Headers) GetContentLength() (int, error) {
-	filtered := h.filter("CONTENT-LENGTH")
-	if len(filtered) >= 2 {
-		return 0, &http.HTTPError{Status: 400, Msg: "Multiple Content-Length is not allowed."}
+// Values returns all values of name in order of appearance. name is case-insensitive.
+func (h Headers) Values(name string) []string {
+	values := []string{}
+	for _, i := range h.positions(name) {
+		values = append(values, h.fields[i].FieldValue)
 	}
-	if len(filtered) == 0 {
-		return 0, nil
+	return values
+}
+
+// Combined returns values of name combined into one list value by ", " (RFC 9110 5.3).
+// It's only for fields defined as list. Set-Cookie can't be combined.
+func (h Headers) Combined(name string) string {
+	return strings.Join(h.Values(name), ", ")
+}
+
+// Set replaces all values of name by value.
+// The field stays at position of its first line if it exists.
+func (h *Headers) Set(name string, value string) {
+	positions := h.positions(name)
+	if len(positions) == 0 {
+		h.Add(name, value)
+		return
 	}
-	length, err := strconv.Atoi(filtered[0].FieldValue)
-	if err != nil {
-		return 0, &http.HTTPError{Msg: fmt.Sprintf("Content-Length:%v is not number.", filtered[0].FieldName)}
+	fields := []Header{}
+	for i, f := range h.list() {
+		if i == positions[0] {
+			fields = append(fields, Header{FieldName: name, FieldValue: value})
+		} else if !strings.EqualFold(f.FieldName, name) {
+			fields = append(fields, f)
+		}
 	}
-	return length, nil
+	h.reindex(fields)
 }
 
-func (h Headers) GetRanges() ([]Range, error) {
-	filtered := h.filter("RANGE")
-	if len(filtered) == 0 {
-		return []Range{}, nil
+// Add appends a field line. Existing values of name are kept.
+func (h *Headers) Add(name string, value string) {
+	h.init()
+	key := strings.ToLower(name)
+	h.fields = append(h.fields, Header{FieldName: name, FieldValue: value})
+	h.index[key] = append(h.index[key], len(h.fields)-1)
+}
+
+// Del removes all values of name.
+func (h *Headers) Del(name string) {
+	if !h.Has(name) {
+		return
+	}
+	fields := []Header{}
+	for _, f := range h.list() {
+		if !strings.EqualFold(f.FieldName, name) {
+			fields = append(fields, f)
+		}
 	}
-	return ParseRange(filtered[0].FieldValue)
+	h.reindex(fields)
 }
 
-func (h Headers) GetAcceptEncodings() []AcceptEncoding {
-	filtered := h.filter("ACCEPT-ENCODING")
-	if len(filtered) == 0 {
-		return []AcceptEncoding{{Coding: CONTENT_CODING_IDENTITY, Weight: 1}}
+// AddVary adds name to Vary header if it's not listed yet.
+func (h *Headers) AddVary(name string) {
+	vary := h.Get("Vary")
+	for _, v := range strings.Split(vary, ",") {
+		if t := strings.TrimSpace(v); strings.EqualFold(t, name) || t == "*" {
+			return
+		}
+	}
+	if vary == "" {
+		h.Set("Vary", name)
+	} else {
+		h.Set("Vary", vary+", "+name)
 	}
+}
 
-	return ParseAcceptEncoding(filtered[0].FieldValue)
+// list returns fields. It's nil for zero value.
+func (h Headers) list() []Header {
+	if h.fieldState == nil {
+		return nil
+	}
+	return h.fields
 }
 
-func (h Headers) GetContentEncodings() []ContentCoding {
-	ces := []ContentCoding{}
-	filtered := h.filter("CONTENT-ENCODING")
-	if len(filtered) == 0 {
-		ces = append(ces, CONTENT_CODING_IDENTITY)
-		return ces
+// positions returns positions of name in fields.
+func (h Headers) positions(name string) []int {
+	if h.fieldState == nil {
+		return nil
 	}
+	return h.index[strings.ToLower(name)]
+}
 
-	vs := strings.Split(filtered[0].FieldValue, ",")
-	for _, v := range vs {
-		ces = append(ces, getContentCoding(strings.TrimSpace(v)))
+// init allocates state of zero value.
+func (h *Headers) init() {
+	if h.fieldState == nil {
+		h.fieldState = &fieldState{index: map[string][]int{}}
 	}
-	return ces
 }
 
-func (h Headers) GetTransferEncodings() []TransferEncoding {
-	var tes = []TransferEncoding{}
-	filtered := h.filter("TRANSFER-ENCODING")
-	for _, header := range filtered {
-		for _, v := range strings.Split(header.FieldName, ",") {
-			tes = append(tes, getTransferEncoding(v))
-		}
+func (h *Headers) reindex(fields []Header) {
+	h.init()
+	h.fields = fields
+	h.index = map[string][]int{}
+	for i, f := range fields {
+		key := strings.ToLower(f.FieldName)
+		h.index[key] = append(h.index[key], i)
 	}
-	return tes
 }
 
-func (h Headers) IsChunkedTransferEncoding() bool {
-	for _, te := range h.GetTransferEncodings() {
-		if te == TRANSFER_ENCODING_CHUNKED {
-			return true
-		}
+func (h Headers) Validate() error {
+	if len(h.Values("HOST")) != 1 {
+		return &http.HTTPError{Status: 400, Msg: "Request require only one Host header."}
 	}
-	return false
+	if h.Has("EXPECT") && !strings.EqualFold(h.Get("EXPECT"), "100-continue") {
+		return &http.HTTPError{Status: 417, Msg: "Expectation Failed"}
+	}
+	if _, err := h.GetContentType(); err != nil {
+		return &http.HTTPError{Status: 400, Msg: err.Error()}
+	}
+	return nil
 }
 
-func (h Headers) GetCompressType() TransferEncoding {
-	for _, te := range h.GetTransferEncodings() {
-		if te != TRANSFER_ENCODING_CHUNKED {
-			return te
+// GetAccept returns media ranges of Accept in order of weight.
+func (h Headers) GetAccept() ([]Accept, error) {
+	return ParseAccept(h.Combined("ACCEPT"))
+}
+
+// GetAcceptLanguages returns language ranges of Accept-Language in order of weight.
+func (h Headers) GetAcceptLanguages() ([]AcceptLanguage, error) {
+	return ParseAcceptLanguage(h.Combined("ACCEPT-LANGUAGE"))
+}
+
+// GetAcceptCharsets returns charsets of Accept-Charset in order of weight.
+func (h Headers) GetAcceptCharsets() ([]AcceptCharset, error) {
+	return ParseAcceptCharset(h.Combined("ACCEPT-CHARSET"))
+}
+
+// IsConnectionClose returns true if Connection has "close" option.
+//
+//	Connection = #connection-option
+func (h Headers) IsConnectionClose() bool {
+	closed := false
+	ParseList(h.Combined("CONNECTION"), func(l *Lexer) error {
+		option, err := l.Token()
+		if err != nil {
+			return err
 		}
+		closed = closed || strings.EqualFold(option, "close")
+		return nil
+	})
+	return closed
+}
+
+func (h Headers) GetContentLength() (int, error) {
+	values := h.Values("CONTENT-LENGTH")
+	if len(values) >= 2 {
+		return 0, &http.HTTPError{Status: 400, Msg: "Multiple Content-Length is not allowed."}
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+	length, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0, &http.HTTPError{Msg: fmt.Sprintf("Content-Length:%v is not number.", values[0]), Status: 400}
 	}
-	return TRANSFER_ENCODING_IDENTITY
+	return length, nil
 }
 
-func (h Headers) GetContentType() ContentType {
-	c := h.filter("CONTENT-TYPE")
-	if len(c) == 0 {
-		return ContentType{contentType: "application/octet-stream"}
+func (h Headers) GetRanges() ([]Range, error) {
+	if !h.Has("RANGE") {
+		return []Range{}, nil
 	}
+	return ParseRange(h.Get("RANGE"))
+}
 
-	s := strings.Split(c[0].FieldValue, ";")
-	if len(s) >= 2 {
-		return ContentType{contentType: s[0], subtype: s[1]}
-	} else {
-		return ContentType{contentType: s[0]}
+func (h Headers) GetAcceptEncodings() ([]AcceptEncoding, error) {
+	if !h.Has("ACCEPT-ENCODING") {
+		return []AcceptEncoding{{Coding: CONTENT_CODING_IDENTITY, Weight: 1}}, nil
 	}
+	return ParseAcceptEncoding(h.Combined("ACCEPT-ENCODING"))
 }
 
-func (h Headers) GetContentLocation() *string {
-	c := h.filter("CONTENT-LOCATION")
-	if len(c) == 0 {
+// GetContentEncodings returns content codings in order of application.
+// It's identity if Content-Encoding doesn't exist.
+//
+//	Content-Encoding = #content-coding
+func (h Headers) GetContentEncodings() ([]ContentCoding, error) {
+	if !h.Has("CONTENT-ENCODING") {
+		return []ContentCoding{CONTENT_CODING_IDENTITY}, nil
+	}
+	ces := []ContentCoding{}
+	err := ParseList(h.Combined("CONTENT-ENCODING"), func(l *Lexer) error {
+		c, err := l.Token()
+		if err != nil {
+			return err
+		}
+		ces = append(ces, getContentCoding(c))
 		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return ces, nil
+}
 
-	return &c[0].FieldValue
+// GetContentType returns parsed Content-Type.
+// It's application/octet-stream if Content-Type doesn't exist (RFC 9110 8.3).
+func (h Headers) GetContentType() (ContentType, error) {
+	if !h.Has("CONTENT-TYPE") {
+		return ContentType{Type: "application", Subtype: "octet-stream", Parameters: []Parameter{}}, nil
+	}
+	return ParseContentType(h.Get("CONTENT-TYPE"))
 }
 
-func (h Headers) filter(key string) Headers {
-	var headers = Headers{}
-	for _, header := range h {
-		if header.FieldName == key {
-			headers = append(headers, header)
-		}
+func (h Headers) GetContentLocation() *string {
+	if !h.Has("CONTENT-LOCATION") {
+		return nil
 	}
-	return headers
+	cl := h.Get("CONTENT-LOCATION")
+	return &cl
 }
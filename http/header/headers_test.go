@@ -0,0 +1,100 @@
+package header
+
+import "testing"
+
+func TestHeaders_CaseInsensitive(t *testing.T) {
+	h := Headers{}
+	h.Add("Accept", "text/html")
+	h.Add("X-Foo", "a")
+	h.Add("ACCEPT", "application/json")
+
+	if h.Get("accept") != "text/html" {
+		t.Errorf("Unexpected value: %v.", h.Get("accept"))
+	}
+	values := h.Values("aCCePT")
+	if len(values) != 2 || values[0] != "text/html" || values[1] != "application/json" {
+		t.Errorf("Unexpected values: %v.", values)
+	}
+	if h.Combined("Accept") != "text/html, application/json" {
+		t.Errorf("Unexpected combined value: %v.", h.Combined("Accept"))
+	}
+	if !h.Has("x-foo") || h.Has("x-bar") {
+		t.Errorf("Unexpected Has.")
+	}
+	if h.Get("x-bar") != "" || len(h.Values("x-bar")) != 0 {
+		t.Errorf("Unexpected value of absent field.")
+	}
+}
+
+func TestHeaders_PreserveNameAndOrder(t *testing.T) {
+	h := NewHeaders(Header{"Host", "example.com"}, Header{"x-lower", "a"}, Header{"Accept", "b"}, Header{"X-Lower", "c"})
+	h.Set("X-LOWER", "d")
+	h.Del("accept")
+	h.Add("Date", "e")
+
+	expected := []Header{{"Host", "example.com"}, {"X-LOWER", "d"}, {"Date", "e"}}
+	actual := h.Fields()
+	if len(actual) != len(expected) {
+		t.Fatalf("Unexpected fields: %v.", actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Unexpected field: %v.", actual[i])
+		}
+	}
+	if h.Get("x-lower") != "d" || h.Get("date") != "e" {
+		t.Errorf("Index is not updated: %v.", h.ToString())
+	}
+}
+
+func TestHeaders_Clone(t *testing.T) {
+	h := NewHeaders(Header{"A", "1"})
+	c := h.Clone()
+	c.Add("B", "2")
+	c.Set("A", "3")
+	if h.Len() != 1 || h.Get("A") != "1" || h.Has("B") {
+		t.Errorf("Original is modified: %v.", h.ToString())
+	}
+}
+
+func TestHeaders_Copy(t *testing.T) {
+	h := NewHeaders(Header{"A", "1"})
+	c := h
+	c.Add("B", "2")
+	c.Del("A")
+	c.Add("C", "3")
+	// copies share fields
+	if h.Len() != 2 || h.Has("A") || h.Get("B") != "2" || h.Get("C") != "3" {
+		t.Errorf("Unexpected original: %v.", h.ToString())
+	}
+
+	// zero value is allocated at first modification of each copy
+	zero := Headers{}
+	z := zero
+	z.Add("A", "1")
+	if zero.Len() != 0 || zero.Get("A") != "" || z.Get("A") != "1" {
+		t.Errorf("Unexpected headers: %v, %v.", zero.ToString(), z.ToString())
+	}
+}
+
+func TestIsConnectionClose(t *testing.T) {
+	tests := []struct {
+		values   []string
+		expected bool
+	}{
+		{[]string{}, false},
+		{[]string{"close"}, true},
+		{[]string{"Keep-Alive, Close"}, true},
+		{[]string{"keep-alive", "close"}, true},
+		{[]string{"keep-alive"}, false},
+	}
+	for _, tt := range tests {
+		h := Headers{}
+		for _, v := range tt.values {
+			h.Add("Connection", v)
+		}
+		if actual := h.IsConnectionClose(); actual != tt.expected {
+			t.Errorf("Unexpected result: %v for %v.", actual, tt.values)
+		}
+	}
+}
@@ -1,11 +1,8 @@
 package header
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
-
-	"github.com/inabajunmr/http11server/http"
 )
 
 type Range struct {
@@ -13,45 +10,72 @@ type Range struct {
 	End   *int
 }
 
+// ParseRange parses Range of bytes unit (RFC 9110 14.2).
+//
+//	ranges-specifier = range-unit "=" range-set
+//	range-set        = 1#range-spec
+//	range-spec       = first-pos "-" [ last-pos ] / "-" suffix-length
 func ParseRange(val string) ([]Range, error) {
-	trim := strings.TrimSpace(val)
-	if !strings.HasPrefix(trim, "bytes=") {
-		return nil, &http.HTTPError{Status: 400, Msg: "Only bytes bytes-unit supported."}
+	l := NewLexer(strings.TrimSpace(val))
+	unit, err := l.Token()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(unit, "bytes") {
+		return nil, l.Errorf("range unit %v is not supported", unit)
+	}
+	if err := l.Expect('='); err != nil {
+		return nil, err
 	}
-	vals := strings.Split(strings.TrimLeft(trim, "bytes="), ",")
+
 	ranges := []Range{}
-	for _, v := range vals {
-		trimV := strings.TrimSpace(v)
-		if strings.HasPrefix(trimV, "-") {
-			end, err := strconv.Atoi(strings.TrimLeft(trimV, "-"))
+	err = l.List(func(l *Lexer) error {
+		if l.Consume('-') {
+			end, err := rangePos(l)
 			if err != nil {
-				return nil, &http.HTTPError{Status: 400, Msg: fmt.Sprintf("Invalid Range header: %v.", val)}
+				return err
 			}
 			ranges = append(ranges, Range{End: &end})
-			continue
-		} else if strings.HasSuffix(trimV, "-") {
-			start, err := strconv.Atoi(strings.TrimRight(trimV, "-"))
-			if err != nil {
-				return nil, &http.HTTPError{Status: 400, Msg: fmt.Sprintf("Invalid Range header: %v.", val)}
-			}
+			return nil
+		}
+		start, err := rangePos(l)
+		if err != nil {
+			return err
+		}
+		if err := l.Expect('-'); err != nil {
+			return err
+		}
+		if c := l.Peek(); c < '0' || c > '9' {
 			ranges = append(ranges, Range{Start: &start})
-			continue
-		} else {
-			se := strings.Split(trimV, "-")
-			if len(se) != 2 {
-				return nil, &http.HTTPError{Status: 400, Msg: fmt.Sprintf("Invalid Range header: %v.", val)}
-			}
-			start, err := strconv.Atoi(se[0])
-			if err != nil {
-				return nil, &http.HTTPError{Status: 400, Msg: fmt.Sprintf("Invalid Range header: %v.", val)}
-			}
-			end, err := strconv.Atoi(se[1])
-			if err != nil {
-				return nil, &http.HTTPError{Status: 400, Msg: fmt.Sprintf("Invalid Range header: %v.", val)}
-			}
-			ranges = append(ranges, Range{Start: &start, End: &end})
-			continue
+			return nil
+		}
+		end, err := rangePos(l)
+		if err != nil {
+			return err
+		}
+		if end < start {
+			return l.Errorf("last-pos is less than first-pos")
 		}
+		ranges = append(ranges, Range{Start: &start, End: &end})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, l.Errorf("range-set is empty")
 	}
 	return ranges, nil
 }
+
+func rangePos(l *Lexer) (int, error) {
+	digits, err := l.Digits()
+	if err != nil {
+		return 0, err
+	}
+	pos, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, l.Errorf("%v is too large", digits)
+	}
+	return pos, nil
+}
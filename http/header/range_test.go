@@ -54,6 +54,40 @@ func TestParseRange_Complex2(t *testing.T) {
 	assertRange(t, ranges[1], nil, intPointer(500))
 }
 
+func TestParseRange_Invalid(t *testing.T) {
+	tests := []struct {
+		value  string
+		offset int
+	}{
+		{"items=0-1", 5},
+		{"bytes 0-1", 5},
+		{"bytes=", 6},
+		{"bytes=a-1", 6},
+		{"bytes=5-1", 9},
+		{"bytes=0-1;2-3", 9},
+		{"bytes=-", 7},
+	}
+	for _, tt := range tests {
+		_, err := ParseRange(tt.value)
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("Unexpected error: %v for %v.", err, tt.value)
+			continue
+		}
+		if perr.Offset != tt.offset {
+			t.Errorf("Unexpected offset: %v for %v.", perr.Offset, tt.value)
+		}
+	}
+}
+
+func TestParseRange_CaseInsensitiveUnit(t *testing.T) {
+	ranges, err := ParseRange("Bytes=0-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRange(t, ranges[0], intPointer(0), intPointer(1))
+}
+
 func assertRange(t *testing.T, r Range, start *int, end *int) {
 	if start != nil && *r.Start != *start {
 		t.Errorf("Unexpected Ranges: %v", r)
@@ -0,0 +1,371 @@
+package sfv
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseItem parses value as Item (RFC 8941 4.2).
+func ParseItem(value string) (Item, error) {
+	p := newParser(value)
+	item, err := p.item()
+	if err != nil {
+		return Item{}, err
+	}
+	return item, p.end()
+}
+
+// ParseList parses value as List (RFC 8941 4.2.1).
+// Empty value is an empty list.
+func ParseList(value string) (List, error) {
+	p := newParser(value)
+	list := List{}
+	for !p.eof() {
+		m, err := p.itemOrInnerList()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+		if err := p.nextMember(); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// ParseDictionary parses value as Dictionary (RFC 8941 4.2.2).
+// Empty value is an empty dictionary. If a key appears twice, the last value is used.
+func ParseDictionary(value string) (Dictionary, error) {
+	p := newParser(value)
+	dict := Dictionary{}
+	for !p.eof() {
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var m Member
+		if p.consume('=') {
+			if m, err = p.itemOrInnerList(); err != nil {
+				return nil, err
+			}
+		} else {
+			params, err := p.params()
+			if err != nil {
+				return nil, err
+			}
+			m = Item{Value: true, Params: params}
+		}
+		dict.set(key, m)
+		if err := p.nextMember(); err != nil {
+			return nil, err
+		}
+	}
+	return dict, nil
+}
+
+type parser struct {
+	value string
+	pos   int
+	limit int
+}
+
+// newParser discards leading and trailing SP of value.
+func newParser(value string) *parser {
+	p := &parser{value: value, limit: len(value)}
+	for p.pos < p.limit && value[p.pos] == ' ' {
+		p.pos++
+	}
+	for p.limit > p.pos && value[p.limit-1] == ' ' {
+		p.limit--
+	}
+	return p
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= p.limit
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.value[p.pos]
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.eof() || p.value[p.pos] != c {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+func (p *parser) skipSP() {
+	for p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+// skipOWS skips OWS = *( SP / HTAB ).
+func (p *parser) skipOWS() {
+	for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) *ParseError {
+	return &ParseError{Value: p.value, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) end() error {
+	if !p.eof() {
+		return p.errorf("unexpected character")
+	}
+	return nil
+}
+
+// nextMember reads separator of List and Dictionary members.
+func (p *parser) nextMember() error {
+	p.skipOWS()
+	if p.eof() {
+		return nil
+	}
+	if !p.consume(',') {
+		return p.errorf("',' is expected")
+	}
+	p.skipOWS()
+	if p.eof() {
+		return p.errorf("trailing ','")
+	}
+	return nil
+}
+
+func (p *parser) itemOrInnerList() (Member, error) {
+	if p.peek() == '(' {
+		return p.innerList()
+	}
+	return p.item()
+}
+
+// inner-list = "(" *SP [ sf-item *( 1*SP sf-item ) *SP ] ")" parameters
+func (p *parser) innerList() (InnerList, error) {
+	p.pos++
+	items := []Item{}
+	for !p.eof() {
+		p.skipSP()
+		if p.consume(')') {
+			params, err := p.params()
+			if err != nil {
+				return InnerList{}, err
+			}
+			return InnerList{Items: items, Params: params}, nil
+		}
+		item, err := p.item()
+		if err != nil {
+			return InnerList{}, err
+		}
+		items = append(items, item)
+		if c := p.peek(); c != ' ' && c != ')' {
+			return InnerList{}, p.errorf("' ' or ')' is expected")
+		}
+	}
+	return InnerList{}, p.errorf("')' is expected")
+}
+
+// sf-item = bare-item parameters
+func (p *parser) item() (Item, error) {
+	v, err := p.bareItem()
+	if err != nil {
+		return Item{}, err
+	}
+	params, err := p.params()
+	if err != nil {
+		return Item{}, err
+	}
+	return Item{Value: v, Params: params}, nil
+}
+
+// parameters = *( ";" *SP param-key [ "=" param-value ] )
+func (p *parser) params() (Params, error) {
+	params := Params{}
+	for p.consume(';') {
+		p.skipSP()
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{} = true
+		if p.consume('=') {
+			if v, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params.set(key, v)
+	}
+	return params, nil
+}
+
+// key = ( lcalpha / "*" ) *( lcalpha / DIGIT / "_" / "-" / "." / "*" )
+func (p *parser) key() (string, error) {
+	start := p.pos
+	if c := p.peek(); !isLCAlpha(c) && c != '*' {
+		return "", p.errorf("key is expected")
+	}
+	p.pos++
+	for !p.eof() && isKeyChar(p.value[p.pos]) {
+		p.pos++
+	}
+	return p.value[start:p.pos], nil
+}
+
+func (p *parser) bareItem() (interface{}, error) {
+	c := p.peek()
+	switch {
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		return p.sfString()
+	case c == '*' || isAlpha(c):
+		return p.token(), nil
+	case c == ':':
+		return p.byteSequence()
+	case c == '?':
+		return p.boolean()
+	}
+	return nil, p.errorf("bare item is expected")
+}
+
+// sf-integer = ["-"] 1*15DIGIT
+// sf-decimal = ["-"] 1*12DIGIT "." 1*3DIGIT
+func (p *parser) number() (interface{}, error) {
+	start := p.pos
+	p.consume('-')
+	digits := p.pos
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	integer := p.pos - digits
+	if integer == 0 {
+		return nil, p.errorf("digit is expected")
+	}
+	if !p.consume('.') {
+		if integer > 15 {
+			return nil, p.errorf("integer is too long")
+		}
+		i, err := strconv.ParseInt(p.value[start:p.pos], 10, 64)
+		if err != nil {
+			return nil, p.errorf("integer is invalid")
+		}
+		return i, nil
+	}
+	if integer > 12 {
+		return nil, p.errorf("integer part of decimal is too long")
+	}
+	fraction := p.pos
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	if p.pos == fraction || p.pos-fraction > 3 {
+		return nil, p.errorf("fractional part of decimal must have 1 to 3 digits")
+	}
+	d, err := strconv.ParseFloat(p.value[start:p.pos], 64)
+	if err != nil {
+		return nil, p.errorf("decimal is invalid")
+	}
+	return d, nil
+}
+
+// sf-string = DQUOTE *chr DQUOTE
+// chr       = unescaped / escaped
+func (p *parser) sfString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		c := p.value[p.pos]
+		p.pos++
+		switch {
+		case c == '"':
+			return b.String(), nil
+		case c == '\\':
+			if c := p.peek(); c != '"' && c != '\\' {
+				return "", p.errorf("invalid escape")
+			}
+			b.WriteByte(p.value[p.pos])
+			p.pos++
+		case c < 0x20 || c > 0x7e:
+			p.pos--
+			return "", p.errorf("invalid character in string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("'\"' is expected")
+}
+
+// sf-token = ( ALPHA / "*" ) *( tchar / ":" / "/" )
+func (p *parser) token() Token {
+	start := p.pos
+	p.pos++
+	for !p.eof() && isTokenChar(p.value[p.pos]) {
+		p.pos++
+	}
+	return Token(p.value[start:p.pos])
+}
+
+// sf-binary = ":" *(base64) ":"
+func (p *parser) byteSequence() ([]byte, error) {
+	p.pos++
+	start := p.pos
+	for !p.eof() && isBase64Char(p.value[p.pos]) {
+		p.pos++
+	}
+	encoded := p.value[start:p.pos]
+	if !p.consume(':') {
+		return nil, p.errorf("':' is expected")
+	}
+	// padding is optional for parsers (RFC 8941 4.2.7)
+	b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, &ParseError{Value: p.value, Offset: start, Msg: "byte sequence is invalid"}
+	}
+	return b, nil
+}
+
+// sf-boolean = "?" boolean
+func (p *parser) boolean() (bool, error) {
+	p.pos++
+	switch {
+	case p.consume('1'):
+		return true, nil
+	case p.consume('0'):
+		return false, nil
+	}
+	return false, p.errorf("'0' or '1' is expected")
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLCAlpha(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isAlpha(c byte) bool {
+	return isLCAlpha(c) || ('A' <= c && c <= 'Z')
+}
+
+func isKeyChar(c byte) bool {
+	return isLCAlpha(c) || isDigit(c) || c == '_' || c == '-' || c == '.' || c == '*'
+}
+
+// tchar / ":" / "/"
+func isTokenChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~:/", c) >= 0
+}
+
+func isBase64Char(c byte) bool {
+	return isAlpha(c) || isDigit(c) || c == '+' || c == '/' || c == '='
+}
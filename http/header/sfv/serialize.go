@@ -0,0 +1,199 @@
+package sfv
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrSerialize is returned for value which can't be serialized.
+var ErrSerialize = errors.New("sfv: value can't be serialized")
+
+// SerializeItem serializes item (RFC 8941 4.1.3).
+func SerializeItem(item Item) (string, error) {
+	var b strings.Builder
+	if err := writeItem(&b, item); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// SerializeList serializes list (RFC 8941 4.1.1).
+// Empty list is serialized to empty string and such field should not be sent.
+func SerializeList(list List) (string, error) {
+	var b strings.Builder
+	for i, m := range list {
+		if i != 0 {
+			b.WriteString(", ")
+		}
+		if err := writeMember(&b, m); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// SerializeDictionary serializes dict (RFC 8941 4.1.2).
+// Empty dictionary is serialized to empty string and such field should not be sent.
+func SerializeDictionary(dict Dictionary) (string, error) {
+	var b strings.Builder
+	for i, m := range dict {
+		if i != 0 {
+			b.WriteString(", ")
+		}
+		if err := writeKey(&b, m.Key); err != nil {
+			return "", err
+		}
+		// true is omitted like a=1, b;foo=9
+		if item, ok := m.Value.(Item); ok && item.Value == true {
+			if err := writeParams(&b, item.Params); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte('=')
+		if err := writeMember(&b, m.Value); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func writeMember(b *strings.Builder, m Member) error {
+	switch m := m.(type) {
+	case Item:
+		return writeItem(b, m)
+	case InnerList:
+		b.WriteByte('(')
+		for i, item := range m.Items {
+			if i != 0 {
+				b.WriteByte(' ')
+			}
+			if err := writeItem(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+		return writeParams(b, m.Params)
+	}
+	return fmt.Errorf("%w: member %T", ErrSerialize, m)
+}
+
+func writeItem(b *strings.Builder, item Item) error {
+	if err := writeBareItem(b, item.Value); err != nil {
+		return err
+	}
+	return writeParams(b, item.Params)
+}
+
+func writeParams(b *strings.Builder, params Params) error {
+	for _, p := range params {
+		b.WriteByte(';')
+		if err := writeKey(b, p.Key); err != nil {
+			return err
+		}
+		if p.Value == true {
+			continue
+		}
+		b.WriteByte('=')
+		if err := writeBareItem(b, p.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeKey(b *strings.Builder, key string) error {
+	if key == "" || (!isLCAlpha(key[0]) && key[0] != '*') {
+		return fmt.Errorf("%w: key %q", ErrSerialize, key)
+	}
+	for i := 1; i < len(key); i++ {
+		if !isKeyChar(key[i]) {
+			return fmt.Errorf("%w: key %q", ErrSerialize, key)
+		}
+	}
+	b.WriteString(key)
+	return nil
+}
+
+func writeBareItem(b *strings.Builder, v interface{}) error {
+	switch v := v.(type) {
+	case int64:
+		return writeInteger(b, v)
+	case int:
+		return writeInteger(b, int64(v))
+	case float64:
+		return writeDecimal(b, v)
+	case string:
+		return writeString(b, v)
+	case Token:
+		return writeToken(b, v)
+	case []byte:
+		b.WriteByte(':')
+		b.WriteString(base64.StdEncoding.EncodeToString(v))
+		b.WriteByte(':')
+		return nil
+	case bool:
+		if v {
+			b.WriteString("?1")
+		} else {
+			b.WriteString("?0")
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: bare item %T", ErrSerialize, v)
+}
+
+func writeInteger(b *strings.Builder, i int64) error {
+	if i < -999999999999999 || i > 999999999999999 {
+		return fmt.Errorf("%w: integer %v is out of range", ErrSerialize, i)
+	}
+	b.WriteString(strconv.FormatInt(i, 10))
+	return nil
+}
+
+// writeDecimal rounds d to 3 fractional digits with round half to even.
+func writeDecimal(b *strings.Builder, d float64) error {
+	rounded := math.RoundToEven(d*1000) / 1000
+	if math.IsNaN(rounded) || math.Abs(rounded) >= 1e12 {
+		return fmt.Errorf("%w: decimal %v is out of range", ErrSerialize, d)
+	}
+	s := strconv.FormatFloat(rounded, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	b.WriteString(s)
+	return nil
+}
+
+func writeString(b *strings.Builder, s string) error {
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c > 0x7e {
+			return fmt.Errorf("%w: string %q has invalid character", ErrSerialize, s)
+		}
+		if c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('"')
+	return nil
+}
+
+func writeToken(b *strings.Builder, t Token) error {
+	if t == "" || (!isAlpha(t[0]) && t[0] != '*') {
+		return fmt.Errorf("%w: token %q", ErrSerialize, t)
+	}
+	for i := 1; i < len(t); i++ {
+		if !isTokenChar(t[i]) {
+			return fmt.Errorf("%w: token %q", ErrSerialize, t)
+		}
+	}
+	b.WriteString(string(t))
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package sfv implements Structured Field Values for HTTP (RFC 8941).
+//
+// Bare items are represented by Go values:
+//
+//	Integer       int64
+//	Decimal       float64
+//	String        string
+//	Token         Token
+//	Byte Sequence []byte
+//	Boolean       bool
+//
+// Serializers also accept int as Integer.
+package sfv
+
+import "fmt"
+
+// Token is a bare item like foo or text/html. It's distinguished from String.
+type Token string
+
+// Item is a bare item with parameters.
+type Item struct {
+	Value  interface{}
+	Params Params
+}
+
+// InnerList is a list of items with parameters like (1 2);a=3.
+type InnerList struct {
+	Items  []Item
+	Params Params
+}
+
+// Member is a member of List or Dictionary. It's Item or InnerList.
+type Member interface {
+	member()
+}
+
+func (Item) member()      {}
+func (InnerList) member() {}
+
+// List is a list of members in order.
+type List []Member
+
+// DictMember is a member of Dictionary with its key.
+type DictMember struct {
+	Key   string
+	Value Member
+}
+
+// Dictionary is an ordered map of keys to members.
+type Dictionary []DictMember
+
+// Get returns member of key.
+func (d Dictionary) Get(key string) (Member, bool) {
+	for _, m := range d {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return nil, false
+}
+
+// set replaces value of key keeping its position or appends it.
+func (d *Dictionary) set(key string, value Member) {
+	for i := range *d {
+		if (*d)[i].Key == key {
+			(*d)[i].Value = value
+			return
+		}
+	}
+	*d = append(*d, DictMember{Key: key, Value: value})
+}
+
+// Param is a parameter with its key. Value is a bare item.
+type Param struct {
+	Key   string
+	Value interface{}
+}
+
+// Params is an ordered map of keys to bare items.
+type Params []Param
+
+// Get returns bare item of key.
+func (p Params) Get(key string) (interface{}, bool) {
+	for _, param := range p {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+	return nil, false
+}
+
+// set replaces value of key keeping its position or appends it.
+func (p *Params) set(key string, value interface{}) {
+	for i := range *p {
+		if (*p)[i].Key == key {
+			(*p)[i].Value = value
+			return
+		}
+	}
+	*p = append(*p, Param{Key: key, Value: value})
+}
+
+// ParseError is returned for malformed structured field.
+type ParseError struct {
+	// Value is the whole field value.
+	Value string
+	// Offset is position in Value where parsing failed.
+	Offset int
+	Msg    string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%v at offset %v of %q.", err.Msg, err.Offset, err.Value)
+}
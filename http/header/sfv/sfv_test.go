@@ -0,0 +1,265 @@
+package sfv
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// vector is a test in the format of https://github.com/httpwg/structured-field-tests.
+type vector struct {
+	Name       string      `json:"name"`
+	Raw        []string    `json:"raw"`
+	HeaderType string      `json:"header_type"`
+	Expected   interface{} `json:"expected"`
+	MustFail   bool        `json:"must_fail"`
+	CanFail    bool        `json:"can_fail"`
+	Canonical  []string    `json:"canonical"`
+}
+
+// rfc9651Only are files of types added by RFC 9651 which this package doesn't implement.
+var rfc9651Only = map[string]bool{"date.json": true, "display-string.json": true}
+
+func TestVectors(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skip("Test vectors are not vendored. Run testdata/update.sh.")
+	}
+	serialisation, err := filepath.Glob("testdata/serialisation-tests/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range append(files, serialisation...) {
+		if rfc9651Only[filepath.Base(file)] {
+			continue
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+		var vectors []vector
+		if err := d.Decode(&vectors); err != nil {
+			t.Fatalf("%v: %v", file, err)
+		}
+		for _, v := range vectors {
+			t.Run(filepath.Base(file)+"/"+v.Name, func(t *testing.T) {
+				testVector(t, v)
+			})
+		}
+	}
+}
+
+func testVector(t *testing.T, v vector) {
+	if v.Raw == nil {
+		testSerialisation(t, v)
+		return
+	}
+	raw := strings.Join(v.Raw, ", ")
+	var actual interface{}
+	var serialized string
+	var err error
+	switch v.HeaderType {
+	case "item":
+		var item Item
+		if item, err = ParseItem(raw); err == nil {
+			actual = item
+			serialized, err = SerializeItem(item)
+		}
+	case "list":
+		var list List
+		if list, err = ParseList(raw); err == nil {
+			actual = list
+			serialized, err = SerializeList(list)
+		}
+	case "dictionary":
+		var dict Dictionary
+		if dict, err = ParseDictionary(raw); err == nil {
+			actual = dict
+			serialized, err = SerializeDictionary(dict)
+		}
+	default:
+		t.Fatalf("Unexpected header_type: %v.", v.HeaderType)
+	}
+
+	if v.MustFail {
+		if err == nil {
+			t.Errorf("Unexpected result: %#v.", actual)
+		}
+		return
+	}
+	if err != nil {
+		if !v.CanFail {
+			t.Errorf("Unexpected error: %v.", err)
+		}
+		return
+	}
+
+	expected := expectedValue(t, v.HeaderType, v.Expected)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result: %#v, expected: %#v.", actual, expected)
+	}
+	canonical := raw
+	if v.Canonical != nil {
+		canonical = strings.Join(v.Canonical, ", ")
+	}
+	if serialized != canonical {
+		t.Errorf("Unexpected serialization: %q, expected: %q.", serialized, canonical)
+	}
+}
+
+// testSerialisation serializes expected of a test in serialisation-tests.
+func testSerialisation(t *testing.T, v vector) {
+	var serialized string
+	var err error
+	switch v.HeaderType {
+	case "item":
+		serialized, err = SerializeItem(expectedItem(t, v.Expected))
+	case "list":
+		serialized, err = SerializeList(expectedValue(t, v.HeaderType, v.Expected).(List))
+	case "dictionary":
+		serialized, err = SerializeDictionary(expectedValue(t, v.HeaderType, v.Expected).(Dictionary))
+	default:
+		t.Fatalf("Unexpected header_type: %v.", v.HeaderType)
+	}
+	if v.MustFail {
+		if err == nil {
+			t.Errorf("Unexpected serialization: %q.", serialized)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error: %v.", err)
+	}
+	if expected := strings.Join(v.Canonical, ", "); serialized != expected {
+		t.Errorf("Unexpected serialization: %q, expected: %q.", serialized, expected)
+	}
+}
+
+// expectedValue converts expected of test vector to Item, List or Dictionary.
+func expectedValue(t *testing.T, headerType string, v interface{}) interface{} {
+	switch headerType {
+	case "item":
+		return expectedItem(t, v)
+	case "list":
+		list := List{}
+		for _, m := range v.([]interface{}) {
+			list = append(list, expectedMember(t, m))
+		}
+		return list
+	}
+	dict := Dictionary{}
+	for _, m := range v.([]interface{}) {
+		kv := m.([]interface{})
+		dict = append(dict, DictMember{Key: kv[0].(string), Value: expectedMember(t, kv[1])})
+	}
+	return dict
+}
+
+// member is [bare item, params] or [[items], params].
+func expectedMember(t *testing.T, v interface{}) Member {
+	pair := v.([]interface{})
+	if items, ok := pair[0].([]interface{}); ok {
+		inner := InnerList{Items: []Item{}, Params: expectedParams(t, pair[1])}
+		for _, item := range items {
+			inner.Items = append(inner.Items, expectedItem(t, item))
+		}
+		return inner
+	}
+	return expectedItem(t, v)
+}
+
+func expectedItem(t *testing.T, v interface{}) Item {
+	pair := v.([]interface{})
+	return Item{Value: expectedBareItem(t, pair[0]), Params: expectedParams(t, pair[1])}
+}
+
+func expectedParams(t *testing.T, v interface{}) Params {
+	params := Params{}
+	for _, p := range v.([]interface{}) {
+		kv := p.([]interface{})
+		params = append(params, Param{Key: kv[0].(string), Value: expectedBareItem(t, kv[1])})
+	}
+	return params
+}
+
+func expectedBareItem(t *testing.T, v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			f, err := v.Float64()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return f
+		}
+		i, err := v.Int64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return i
+	case string, bool:
+		return v
+	case map[string]interface{}:
+		value := v["value"].(string)
+		switch v["__type"] {
+		case "token":
+			return Token(value)
+		case "binary":
+			b, err := base32.StdEncoding.DecodeString(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return b
+		}
+	}
+	t.Fatalf("Unexpected bare item: %#v.", v)
+	return nil
+}
+
+func TestParseError(t *testing.T) {
+	_, err := ParseDictionary("a=1, B=2")
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Unexpected error: %v.", err)
+	}
+	if perr.Offset != 5 {
+		t.Errorf("Unexpected offset: %v.", perr.Offset)
+	}
+}
+
+func TestSerialize_Invalid(t *testing.T) {
+	items := []Item{
+		{Value: int64(1000000000000000)},
+		{Value: 1e12},
+		{Value: "ü"},
+		{Value: Token("1a")},
+		{Value: struct{}{}},
+		{Value: int64(1), Params: Params{{Key: "A", Value: true}}},
+	}
+	for _, item := range items {
+		if s, err := SerializeItem(item); err == nil {
+			t.Errorf("Unexpected serialization: %v for %#v.", s, item)
+		}
+	}
+}
+
+func TestSerializeItem_RoundDecimal(t *testing.T) {
+	// 0.0625 and 0.1875 are exact in binary so they are rounded half to even
+	tests := map[float64]string{0.0625: "0.062", 0.1875: "0.188", -0.5: "-0.5", 3: "3.0", 1.23456: "1.235"}
+	for d, expected := range tests {
+		actual, err := SerializeItem(Item{Value: d})
+		if err != nil || actual != expected {
+			t.Errorf("Unexpected serialization: %v, %v for %v.", actual, err, d)
+		}
+	}
+}
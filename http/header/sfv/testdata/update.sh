@@ -0,0 +1,30 @@
+#!/bin/sh
+# Vendors test vectors of https://github.com/httpwg/structured-field-tests unchanged.
+# The vendored commit is written to UPSTREAM.
+# commit is required so that vendored files are always pinned.
+#
+# Usage: ./update.sh <commit>
+set -eu
+
+if [ $# -ne 1 ]; then
+	echo "Usage: $0 <commit>" >&2
+	exit 2
+fi
+ref=$1
+dir=$(mktemp -d)
+trap 'rm -rf "$dir"' EXIT
+
+git clone -q https://github.com/httpwg/structured-field-tests.git "$dir"
+git -C "$dir" checkout -q "$ref"
+
+cd "$(dirname "$0")"
+rm -rf ./*.json serialisation-tests
+mkdir serialisation-tests
+cp "$dir"/*.json .
+cp "$dir"/serialisation-tests/*.json serialisation-tests/
+for license in "$dir"/LICENSE*; do
+	if [ -f "$license" ]; then
+		cp "$license" .
+	fi
+done
+git -C "$dir" rev-parse HEAD > UPSTREAM
@@ -0,0 +1,29 @@
+package header
+
+import "github.com/inabajunmr/http11server/http/header/sfv"
+
+// GetStructuredItem returns field of name parsed as Item of Structured Field Values (RFC 8941).
+// Field lines are combined as a list, so a field having multiple lines is invalid.
+// It returns false if the field doesn't exist.
+func (h Headers) GetStructuredItem(name string) (sfv.Item, bool, error) {
+	if !h.Has(name) {
+		return sfv.Item{}, false, nil
+	}
+	item, err := sfv.ParseItem(h.Combined(name))
+	if err != nil {
+		return sfv.Item{}, true, err
+	}
+	return item, true, nil
+}
+
+// GetStructuredList returns field of name parsed as List of Structured Field Values (RFC 8941).
+// It's empty if the field doesn't exist.
+func (h Headers) GetStructuredList(name string) (sfv.List, error) {
+	return sfv.ParseList(h.Combined(name))
+}
+
+// GetStructuredDictionary returns field of name parsed as Dictionary of Structured Field Values (RFC 8941).
+// It's empty if the field doesn't exist.
+func (h Headers) GetStructuredDictionary(name string) (sfv.Dictionary, error) {
+	return sfv.ParseDictionary(h.Combined(name))
+}
@@ -0,0 +1,48 @@
+package header
+
+import (
+	"testing"
+
+	"github.com/inabajunmr/http11server/http/header/sfv"
+)
+
+func TestGetStructuredDictionary(t *testing.T) {
+	h := NewHeaders(Header{FieldName: "PRIORITY", FieldValue: "u=1"}, Header{FieldName: "PRIORITY", FieldValue: "i"})
+	actual, err := h.GetStructuredDictionary("Priority")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := actual.Get("u"); u.(sfv.Item).Value != int64(1) {
+		t.Errorf("Unexpected u: %v.", u)
+	}
+	if i, _ := actual.Get("i"); i.(sfv.Item).Value != true {
+		t.Errorf("Unexpected i: %v.", i)
+	}
+}
+
+func TestGetStructuredItem(t *testing.T) {
+	h := NewHeaders(Header{FieldName: "X-Item", FieldValue: `"a";b=?0`})
+	item, ok, err := h.GetStructuredItem("x-item")
+	if err != nil || !ok {
+		t.Fatalf("Unexpected result: %v, %v.", ok, err)
+	}
+	if b, _ := item.Params.Get("b"); item.Value != "a" || b != false {
+		t.Errorf("Unexpected item: %v.", item)
+	}
+
+	if _, ok, _ := h.GetStructuredItem("x-none"); ok {
+		t.Errorf("Absent field is found.")
+	}
+
+	h.Add("X-Item", "1")
+	if _, _, err := h.GetStructuredItem("x-item"); err == nil {
+		t.Errorf("Multiple field lines are accepted as item.")
+	}
+}
+
+func TestGetStructuredList_Absent(t *testing.T) {
+	actual, err := Headers{}.GetStructuredList("Cache-Status")
+	if err != nil || len(actual) != 0 {
+		t.Errorf("Unexpected result: %v, %v.", actual, err)
+	}
+}
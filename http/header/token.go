@@ -0,0 +1,84 @@
+package header
+
+// IsTokenChar returns true if c is tchar.
+//
+//	tchar = "!" / "#" / "$" / "%" / "&" / "'" / "*" / "+" / "-" / "." /
+//	        "^" / "_" / "`" / "|" / "~" / DIGIT / ALPHA
+func IsTokenChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	switch c {
+	case '!', '#', '$', '%', '&', '\'', '*', '+', '-', '.', '^', '_', '`', '|', '~':
+		return true
+	}
+	return false
+}
+
+// ReadQuotedString reads quoted-string at the beginning of s.
+// It returns unescaped value and length of quoted-string in s.
+func ReadQuotedString(s string) (string, int, bool) {
+	if len(s) == 0 || s[0] != '"' {
+		return "", 0, false
+	}
+	value := []byte{}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return string(value), i + 1, true
+		case c == '\\':
+			i++
+			if i == len(s) || !isQuotedPairChar(s[i]) {
+				return "", 0, false
+			}
+			value = append(value, s[i])
+		case isQdtext(c):
+			value = append(value, c)
+		default:
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+// qdtext = HTAB / SP / %x21 / %x23-5B / %x5D-7E / obs-text
+func isQdtext(c byte) bool {
+	return c == '\t' || c == ' ' || c == 0x21 || (0x23 <= c && c <= 0x5B) || (0x5D <= c && c <= 0x7E) || c >= 0x80
+}
+
+// quoted-pair = "\" ( HTAB / SP / VCHAR / obs-text )
+func isQuotedPairChar(c byte) bool {
+	return c == '\t' || c == ' ' || (0x21 <= c && c <= 0x7E) || c >= 0x80
+}
+
+// ReadToken reads token at the beginning of s and returns its length.
+func ReadToken(s string) (string, int) {
+	n := 0
+	for n < len(s) && IsTokenChar(s[n]) {
+		n++
+	}
+	return s[:n], n
+}
+
+// IsToken returns true if s is non-empty token.
+func IsToken(s string) bool {
+	_, n := ReadToken(s)
+	return n != 0 && n == len(s)
+}
+
+// QuoteString returns s as it is if it's token. Otherwise it returns quoted-string of s.
+func QuoteString(s string) string {
+	if IsToken(s) {
+		return s
+	}
+	quoted := []byte{'"'}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			quoted = append(quoted, '\\')
+		}
+		quoted = append(quoted, s[i])
+	}
+	return string(append(quoted, '"'))
+}
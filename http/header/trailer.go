@@ -0,0 +1,72 @@
+package header
+
+import "strings"
+
+// forbiddenTrailers are fields not allowed in trailer section (RFC 9110 6.5.1).
+// They are about message framing, routing, request modifiers, authentication,
+// response control data and payload processing.
+var forbiddenTrailers = []string{
+	"TRANSFER-ENCODING", "CONTENT-LENGTH", "TRAILER",
+	"HOST",
+	"CACHE-CONTROL", "EXPECT", "MAX-FORWARDS", "PRAGMA", "RANGE", "TE",
+	"IF-MATCH", "IF-NONE-MATCH", "IF-MODIFIED-SINCE", "IF-UNMODIFIED-SINCE", "IF-RANGE",
+	"AUTHORIZATION", "PROXY-AUTHORIZATION", "PROXY-AUTHENTICATE", "WWW-AUTHENTICATE", "COOKIE", "SET-COOKIE",
+	"AGE", "DATE", "EXPIRES", "LOCATION", "RETRY-AFTER", "VARY", "WARNING",
+	"CONTENT-ENCODING", "CONTENT-TYPE", "CONTENT-RANGE",
+	"CONNECTION", "KEEP-ALIVE", "UPGRADE", "PROXY-CONNECTION",
+}
+
+// IsForbiddenTrailer returns true if field name must not be sent as trailer.
+func IsForbiddenTrailer(name string) bool {
+	for _, f := range forbiddenTrailers {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTrailer returns field names declared by Trailer header.
+//
+//	Trailer = #field-name
+func (h Headers) GetTrailer() ([]string, error) {
+	names := []string{}
+	err := ParseList(h.Combined("TRAILER"), func(l *Lexer) error {
+		name, err := l.Token()
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// IsDeclaredTrailer returns true if name is declared by Trailer header.
+// Nothing is declared by invalid Trailer.
+func (h Headers) IsDeclaredTrailer(name string) bool {
+	names, _ := h.GetTrailer()
+	for _, t := range names {
+		if strings.EqualFold(t, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsTrailers returns true if TE header has "trailers". It's false for invalid TE.
+func (h Headers) AcceptsTrailers() bool {
+	codings, err := parseTransferCodings(h.Combined("TE"))
+	if err != nil {
+		return false
+	}
+	for _, c := range codings {
+		if strings.EqualFold(c.name, "trailers") {
+			return true
+		}
+	}
+	return false
+}
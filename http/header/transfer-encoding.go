@@ -1,5 +1,10 @@
 package header
 
+import (
+	"sort"
+	"strings"
+)
+
 type TransferEncoding int
 
 const (
@@ -8,20 +13,123 @@ const (
 	TRANSFER_ENCODING_DEFLATE
 	TRANSFER_ENCODING_GZIP
 	TRANSFER_ENCODING_IDENTITY
+	TRANSFER_ENCODING_UNKNOWN
 )
 
 func getTransferEncoding(v string) TransferEncoding {
-	switch v {
+	switch strings.ToLower(v) {
 	case "chunked":
 		return TRANSFER_ENCODING_CHUNKED
-	case "compress":
+	case "compress", "x-compress":
 		return TRANSFER_ENCODING_COMPRESS
 	case "deflate":
 		return TRANSFER_ENCODING_DEFLATE
-	case "gzip":
+	case "gzip", "x-gzip":
 		return TRANSFER_ENCODING_GZIP
 	case "identity":
 		return TRANSFER_ENCODING_IDENTITY
 	}
-	return 0 // TODO
+	return TRANSFER_ENCODING_UNKNOWN
+}
+
+func (te TransferEncoding) ToString() string {
+	switch te {
+	case TRANSFER_ENCODING_CHUNKED:
+		return "chunked"
+	case TRANSFER_ENCODING_COMPRESS:
+		return "compress"
+	case TRANSFER_ENCODING_DEFLATE:
+		return "deflate"
+	case TRANSFER_ENCODING_GZIP:
+		return "gzip"
+	case TRANSFER_ENCODING_IDENTITY:
+		return "identity"
+	}
+	return ""
+}
+
+// transferCoding is an element of Transfer-Encoding or TE.
+type transferCoding struct {
+	name   string
+	params []Parameter
+}
+
+// parseTransferCodings parses list of transfer-coding = token *( OWS ";" OWS transfer-parameter ).
+func parseTransferCodings(value string) ([]transferCoding, error) {
+	codings := []transferCoding{}
+	err := ParseList(value, func(l *Lexer) error {
+		name, err := l.Token()
+		if err != nil {
+			return err
+		}
+		params, err := l.Parameters()
+		if err != nil {
+			return err
+		}
+		codings = append(codings, transferCoding{name: name, params: params})
+		return nil
+	})
+	return codings, err
+}
+
+// GetTransferEncodings returns transfer codings in order of application.
+func (h Headers) GetTransferEncodings() ([]TransferEncoding, error) {
+	codings, err := parseTransferCodings(h.Combined("TRANSFER-ENCODING"))
+	if err != nil {
+		return nil, err
+	}
+	tes := []TransferEncoding{}
+	for _, c := range codings {
+		tes = append(tes, getTransferEncoding(c.name))
+	}
+	return tes, nil
+}
+
+// IsChunkedTransferEncoding returns true if chunked is applied. It's false for invalid Transfer-Encoding.
+func (h Headers) IsChunkedTransferEncoding() bool {
+	tes, _ := h.GetTransferEncodings()
+	for _, te := range tes {
+		if te == TRANSFER_ENCODING_CHUNKED {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAcceptTransferEncodings returns transfer codings accepted by TE header in order of weight.
+// "trailers", chunked, unknown codings and codings with q=0 are not included.
+//
+//	TE       = #t-codings
+//	t-codings = "trailers" / ( transfer-coding [ weight ] )
+func (h Headers) GetAcceptTransferEncodings() ([]TransferEncoding, error) {
+	type weighted struct {
+		te     TransferEncoding
+		weight float64
+	}
+	codings, err := parseTransferCodings(h.Combined("TE"))
+	if err != nil {
+		return nil, err
+	}
+	accepts := []weighted{}
+	for _, c := range codings {
+		te := getTransferEncoding(c.name)
+		if te == TRANSFER_ENCODING_CHUNKED || te == TRANSFER_ENCODING_UNKNOWN {
+			continue
+		}
+		weight, err := Weight(c.params)
+		if err != nil {
+			return nil, err
+		}
+		if weight > 0 {
+			accepts = append(accepts, weighted{te, weight})
+		}
+	}
+	sort.SliceStable(accepts, func(i, j int) bool {
+		return accepts[i].weight > accepts[j].weight
+	})
+	tes := []TransferEncoding{}
+	for _, a := range accepts {
+		tes = append(tes, a.te)
+	}
+	return tes, nil
 }
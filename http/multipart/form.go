@@ -0,0 +1,149 @@
+package multipart
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+// Form is multipart/form-data read by ReadForm.
+type Form struct {
+	// Parts are all parts in order of appearance.
+	Parts []*FormPart
+}
+
+// FormPart is a part read by ReadForm. Its content is kept in memory or a temp file.
+type FormPart struct {
+	Headers header.Headers
+	// Name is name parameter of Content-Disposition.
+	Name string
+	// FileName is filename parameter of Content-Disposition without directory path.
+	// It's empty for parts which are not files.
+	FileName string
+	// Size is length of content.
+	Size int64
+
+	content []byte
+	// tmpfile is path of temp file having content. It's empty if content is in memory.
+	tmpfile string
+}
+
+// ReadForm reads all parts from r.
+// Parts are kept in memory while total of their sizes doesn't exceed maxMemory.
+// File parts exceeding it are stored to temp files and other parts exceeding it responds 413.
+// More than maxParts parts responds 400.
+// Temp files must be removed by RemoveAll even if ReadForm returns error.
+func ReadForm(r *Reader, maxMemory int64, maxParts int) (*Form, error) {
+	form := &Form{Parts: []*FormPart{}}
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return form, err
+		}
+		if len(form.Parts) == maxParts {
+			return form, &http.HTTPError{Msg: "Multipart form has too many parts.", Status: 400}
+		}
+		fp := &FormPart{Headers: p.Headers, Name: p.Name, FileName: p.FileName}
+		form.Parts = append(form.Parts, fp)
+
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, io.LimitReader(p, maxMemory+1))
+		if err != nil {
+			return form, err
+		}
+		if n <= maxMemory {
+			maxMemory -= n
+			fp.content, fp.Size = buf.Bytes(), n
+			continue
+		}
+		if fp.FileName == "" {
+			return form, &http.HTTPError{Msg: fmt.Sprintf("Multipart form field %v is too large.", fp.Name), Status: 413}
+		}
+		if err := fp.spill(buf.Bytes(), p); err != nil {
+			return form, err
+		}
+	}
+}
+
+// spill writes head and rest of the part to a temp file.
+func (p *FormPart) spill(head []byte, rest io.Reader) error {
+	f, err := ioutil.TempFile("", "multipart-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p.tmpfile = f.Name()
+	n, err := io.Copy(f, io.MultiReader(bytes.NewReader(head), rest))
+	if err != nil {
+		return err
+	}
+	p.Size = n
+	return nil
+}
+
+// ContentType returns Content-Type of part. It's text/plain if part doesn't have it (RFC 7578 4.4).
+func (p *FormPart) ContentType() header.ContentType {
+	return contentType(p.Headers)
+}
+
+// Open returns reader of content.
+func (p *FormPart) Open() (io.ReadCloser, error) {
+	if p.tmpfile != "" {
+		return os.Open(p.tmpfile)
+	}
+	return ioutil.NopCloser(bytes.NewReader(p.content)), nil
+}
+
+// Value returns content of the first part of name which is not a file.
+func (f *Form) Value(name string) (string, bool) {
+	for _, p := range f.Parts {
+		if p.Name == name && p.FileName == "" {
+			return string(p.content), true
+		}
+	}
+	return "", false
+}
+
+// Values returns contents of parts which are not files by names.
+func (f *Form) Values() map[string][]string {
+	values := map[string][]string{}
+	for _, p := range f.Parts {
+		if p.FileName == "" {
+			values[p.Name] = append(values[p.Name], string(p.content))
+		}
+	}
+	return values
+}
+
+// Files returns file parts of name.
+func (f *Form) Files(name string) []*FormPart {
+	files := []*FormPart{}
+	for _, p := range f.Parts {
+		if p.Name == name && p.FileName != "" {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// RemoveAll removes temp files of parts.
+func (f *Form) RemoveAll() error {
+	var err error
+	for _, p := range f.Parts {
+		if p.tmpfile == "" {
+			continue
+		}
+		if e := os.Remove(p.tmpfile); e != nil && !os.IsNotExist(e) && err == nil {
+			err = e
+		}
+	}
+	return err
+}
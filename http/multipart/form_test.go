@@ -0,0 +1,87 @@
+package multipart
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inabajunmr/http11server/http"
+)
+
+func TestReadForm(t *testing.T) {
+	r, _ := NewReader(strings.NewReader(body), "b0undary")
+	// "value\r\n--b0undar" is kept in memory and the file is stored to temp file
+	form, err := ReadForm(r, 20, 10)
+	defer form.RemoveAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(form.Values(), map[string][]string{"field": {"value\r\n--b0undar"}, "empty": {""}}) {
+		t.Errorf("Unexpected values: %v.", form.Values())
+	}
+	if v, ok := form.Value("field"); !ok || v != "value\r\n--b0undar" {
+		t.Errorf("Unexpected value: %v.", v)
+	}
+
+	files := form.Files("file")
+	if len(files) != 1 || files[0].FileName != "a.txt" || files[0].Size != 8 {
+		t.Fatalf("Unexpected files: %v.", files)
+	}
+	tmpfile := files[0].tmpfile
+	if tmpfile == "" {
+		t.Fatal("File is not stored to temp file.")
+	}
+	f, err := files[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(f)
+	f.Close()
+	if string(b) != "a,b\r\n1,2" {
+		t.Errorf("Unexpected content: %q.", string(b))
+	}
+
+	if err := form.RemoveAll(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tmpfile); !os.IsNotExist(err) {
+		t.Errorf("Temp file is not removed: %v.", err)
+	}
+}
+
+func TestReadForm_InMemory(t *testing.T) {
+	r, _ := NewReader(strings.NewReader(body), "b0undary")
+	form, err := ReadForm(r, 1024, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range form.Parts {
+		if p.tmpfile != "" {
+			t.Errorf("Unexpected temp file: %v.", p.Name)
+		}
+	}
+}
+
+func TestReadForm_Limits(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxMemory int64
+		maxParts  int
+		status    int
+	}{
+		{name: "too large field", maxMemory: 5, maxParts: 10, status: 413},
+		{name: "too many parts", maxMemory: 1024, maxParts: 2, status: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := NewReader(strings.NewReader(body), "b0undary")
+			form, err := ReadForm(r, tt.maxMemory, tt.maxParts)
+			form.RemoveAll()
+			if httpErr, ok := err.(*http.HTTPError); !ok || httpErr.Status != tt.status {
+				t.Errorf("Unexpected error: %v.", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+// Package multipart implements streaming parser of multipart/form-data (RFC 7578, RFC 2046 5.1).
+//
+// Reader reads parts one by one from body without buffering whole body.
+// ReadForm reads all parts and stores large file parts to temp files.
+package multipart
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+const (
+	// maxPartHeaderBytes limits total length of header field lines of a part.
+	maxPartHeaderBytes = 16 * 1024
+	// maxPartHeaderCount limits number of header fields of a part.
+	maxPartHeaderCount = 32
+)
+
+// Reader reads parts of multipart body.
+type Reader struct {
+	reader *bufio.Reader
+	// dashBoundary is "--" boundary. It starts the first part.
+	dashBoundary []byte
+	// delimiter is CRLF "--" boundary. It ends each part.
+	delimiter []byte
+	current   *Part
+	started   bool
+	done      bool
+}
+
+// NewReader returns Reader reading r. boundary is boundary parameter of Content-Type.
+func NewReader(r io.Reader, boundary string) (*Reader, error) {
+	if !validBoundary(boundary) {
+		return nil, &http.HTTPError{Msg: fmt.Sprintf("Multipart boundary %v is invalid.", boundary), Status: 400}
+	}
+	return &Reader{
+		reader:       bufio.NewReader(r),
+		dashBoundary: []byte("--" + boundary),
+		delimiter:    []byte("\r\n--" + boundary),
+	}, nil
+}
+
+// boundary      = 0*69<bchars> bcharsnospace
+// bchars        = bcharsnospace / " "
+// bcharsnospace = DIGIT / ALPHA / "'" / "(" / ")" / "+" / "_" / "," / "-" / "." / "/" / ":" / "=" / "?"
+func validBoundary(boundary string) bool {
+	if len(boundary) == 0 || len(boundary) > 70 || strings.HasSuffix(boundary, " ") {
+		return false
+	}
+	for i := 0; i < len(boundary); i++ {
+		c := boundary[i]
+		if !isAlphaNum(c) && strings.IndexByte("'()+_,-./:=? ", c) == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphaNum(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// NextPart returns next part. Rest of previous part is discarded.
+// It returns io.EOF after close delimiter. Epilogue is not read.
+func (r *Reader) NextPart() (*Part, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if r.current != nil {
+		if _, err := io.Copy(ioutil.Discard, r.current); err != nil {
+			return nil, err
+		}
+		r.current = nil
+	}
+
+	var err error
+	if !r.started {
+		err = r.skipPreamble()
+		r.started = true
+	} else {
+		err = r.readDelimiterLine()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.done {
+		return nil, io.EOF
+	}
+
+	headers, err := r.readHeaders()
+	if err != nil {
+		return nil, err
+	}
+	part, err := newPart(r, *headers)
+	if err != nil {
+		return nil, err
+	}
+	r.current = part
+	return part, nil
+}
+
+// skipPreamble reads lines until dash-boundary.
+func (r *Reader) skipPreamble() error {
+	for {
+		line, err := r.reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// long preamble line can't be dash-boundary
+			continue
+		}
+		if bytes.HasPrefix(line, r.dashBoundary) {
+			return r.afterBoundary(line[len(r.dashBoundary):])
+		}
+		if err != nil {
+			return bodyError("Multipart body has no boundary.", err)
+		}
+	}
+}
+
+// readDelimiterLine reads delimiter at the end of part and rest of the line.
+func (r *Reader) readDelimiterLine() error {
+	if _, err := r.reader.Discard(len(r.delimiter)); err != nil {
+		return bodyError("Multipart body is not terminated.", err)
+	}
+	line, err := r.reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return &http.HTTPError{Msg: "Multipart boundary line is invalid.", Status: 400}
+	}
+	if err != nil && !(err == io.EOF && bytes.HasPrefix(line, []byte("--"))) {
+		return bodyError("Multipart body is not terminated.", err)
+	}
+	return r.afterBoundary(line)
+}
+
+// afterBoundary checks rest of the boundary line.
+// It's "--" for close delimiter or transport-padding CRLF.
+func (r *Reader) afterBoundary(rest []byte) error {
+	if bytes.HasPrefix(rest, []byte("--")) {
+		r.done = true
+		return nil
+	}
+	if len(bytes.TrimRight(rest, " \t\r\n")) != 0 || !bytes.HasSuffix(rest, []byte("\r\n")) {
+		return &http.HTTPError{Msg: "Multipart boundary line is invalid.", Status: 400}
+	}
+	return nil
+}
+
+func (r *Reader) readHeaders() (*header.Headers, error) {
+	headers := header.Headers{}
+	total := 0
+	for {
+		line, err := r.reader.ReadSlice('\n')
+		total += len(line)
+		if err == bufio.ErrBufferFull || total > maxPartHeaderBytes {
+			return nil, &http.HTTPError{Msg: "Multipart part header is too large.", Status: 400}
+		}
+		if err != nil {
+			return nil, bodyError("Multipart part header is not terminated.", err)
+		}
+		if !bytes.HasSuffix(line, []byte("\r\n")) {
+			return nil, &http.HTTPError{Msg: "Multipart part header line must end with CRLF.", Status: 400}
+		}
+		l := string(line[:len(line)-2])
+		if l == "" {
+			return &headers, nil
+		}
+		if headers.Len() == maxPartHeaderCount {
+			return nil, &http.HTTPError{Msg: "Multipart part has too many header fields.", Status: 400}
+		}
+		h, err := header.ParseHeader(l)
+		if err != nil {
+			return nil, &http.HTTPError{Msg: fmt.Sprintf("Multipart part header %v is invalid.", l), Status: 400}
+		}
+		headers.Add(h.FieldName, h.FieldValue)
+	}
+}
+
+// bodyError returns HTTPError for unexpected EOF and err as it is for others like errors of body limits.
+func bodyError(msg string, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &http.HTTPError{Msg: msg, Status: 400}
+	}
+	return err
+}
+
+// Part is a part of multipart body. Read reads its content until delimiter.
+type Part struct {
+	Headers header.Headers
+	// Name is name parameter of Content-Disposition.
+	Name string
+	// FileName is filename parameter of Content-Disposition without directory path.
+	// It's empty for parts which are not files.
+	FileName string
+	reader   *Reader
+	eof      bool
+}
+
+func newPart(r *Reader, headers header.Headers) (*Part, error) {
+	cd, ok, err := headers.GetContentDisposition()
+	if err != nil || !ok || cd.Type != "form-data" {
+		return nil, &http.HTTPError{Msg: "Multipart part must have Content-Disposition: form-data.", Status: 400}
+	}
+	name, ok := cd.Parameter("name")
+	if !ok {
+		return nil, &http.HTTPError{Msg: "Multipart part must have name.", Status: 400}
+	}
+	if _, err := headers.GetContentType(); err != nil {
+		return nil, &http.HTTPError{Msg: fmt.Sprintf("Multipart part %v has invalid Content-Type.", name), Status: 400}
+	}
+	fileName, _ := cd.Parameter("filename")
+	// directory path must not be used (RFC 7578 4.2)
+	if i := strings.LastIndexAny(fileName, `/\`); i != -1 {
+		fileName = fileName[i+1:]
+	}
+	return &Part{Headers: headers, Name: name, FileName: fileName, reader: r}, nil
+}
+
+// ContentType returns Content-Type of part. It's text/plain if part doesn't have it (RFC 7578 4.4).
+func (p *Part) ContentType() header.ContentType {
+	return contentType(p.Headers)
+}
+
+func contentType(headers header.Headers) header.ContentType {
+	if !headers.Has("Content-Type") {
+		return header.ContentType{Type: "text", Subtype: "plain", Parameters: []header.Parameter{}}
+	}
+	// it's validated by newPart
+	ct, _ := headers.GetContentType()
+	return ct
+}
+
+// Read reads content of part. Bytes which may be a part of delimiter are kept in buffer
+// until enough bytes are read to decide.
+func (p *Part) Read(b []byte) (int, error) {
+	if p.eof {
+		return 0, io.EOF
+	}
+	r := p.reader.reader
+	delimiter := p.reader.delimiter
+	var err error
+	if r.Buffered() < len(delimiter) {
+		_, err = r.Peek(len(delimiter))
+	}
+	buffered, _ := r.Peek(r.Buffered())
+	if i := bytes.Index(buffered, delimiter); i != -1 {
+		n := copy(b, buffered[:i])
+		r.Discard(n)
+		if n == i {
+			p.eof = true
+			if n == 0 {
+				return 0, io.EOF
+			}
+		}
+		return n, nil
+	}
+	if err != nil {
+		return 0, bodyError("Multipart body is not terminated.", err)
+	}
+	n := copy(b, buffered[:len(buffered)-len(delimiter)+1])
+	r.Discard(n)
+	return n, nil
+}
@@ -0,0 +1,121 @@
+package multipart
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/inabajunmr/http11server/http"
+)
+
+const body = "preamble\r\n" +
+	"--b0undary \r\n" +
+	"Content-Disposition: form-data; name=\"field\"\r\n" +
+	"\r\n" +
+	"value\r\n--b0undar\r\n" +
+	"--b0undary\r\n" +
+	"Content-Disposition: form-data; name=\"file\"; filename=\"C:\\\\dir\\\\a.txt\"\r\n" +
+	"Content-Type: text/csv; charset=utf-8\r\n" +
+	"\r\n" +
+	"a,b\r\n1,2\r\n" +
+	"--b0undary\r\n" +
+	"Content-Disposition: form-data; name=\"empty\"\r\n" +
+	"\r\n" +
+	"\r\n--b0undary--\r\n" +
+	"epilogue"
+
+func TestReader(t *testing.T) {
+	readers := map[string]io.Reader{
+		"whole":    strings.NewReader(body),
+		"one byte": iotest.OneByteReader(strings.NewReader(body)),
+	}
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(reader, "b0undary")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []struct {
+				name, fileName, contentType, content string
+			}{
+				{"field", "", "text/plain", "value\r\n--b0undar"},
+				{"file", "a.txt", "text/csv; charset=utf-8", "a,b\r\n1,2"},
+				{"empty", "", "text/plain", ""},
+			}
+			for _, e := range expected {
+				p, err := r.NextPart()
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := ioutil.ReadAll(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if p.Name != e.name || p.FileName != e.fileName || p.ContentType().ToString() != e.contentType || string(b) != e.content {
+					t.Errorf("Unexpected part: %v %v %v %q.", p.Name, p.FileName, p.ContentType().ToString(), string(b))
+				}
+			}
+			if p, err := r.NextPart(); err != io.EOF {
+				t.Errorf("Unexpected part: %v, %v.", p, err)
+			}
+		})
+	}
+}
+
+func TestReader_SkipUnreadPart(t *testing.T) {
+	r, _ := NewReader(strings.NewReader(body), "b0undary")
+	names := []string{}
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "field,file,empty" {
+		t.Errorf("Unexpected names: %v.", names)
+	}
+}
+
+func TestNewReader_InvalidBoundary(t *testing.T) {
+	for _, boundary := range []string{"", "a ", strings.Repeat("a", 71), "a\"b", "a;b"} {
+		if _, err := NewReader(strings.NewReader(""), boundary); err == nil {
+			t.Errorf("Unexpected success: %q.", boundary)
+		}
+	}
+}
+
+func TestReader_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no boundary":            "aaaa",
+		"not terminated":         "--b\r\nContent-Disposition: form-data; name=a\r\n\r\nvalue",
+		"no close delimiter":     "--b\r\nContent-Disposition: form-data; name=a\r\n\r\nvalue\r\n--b",
+		"no content-disposition": "--b\r\nContent-Type: text/plain\r\n\r\nvalue\r\n--b--",
+		"no name":                "--b\r\nContent-Disposition: form-data\r\n\r\nvalue\r\n--b--",
+		"invalid content-type":   "--b\r\nContent-Disposition: form-data; name=a\r\nContent-Type: text\r\n\r\nvalue\r\n--b--",
+		"invalid header":         "--b\r\nContent-Disposition form-data\r\n\r\nvalue\r\n--b--",
+		"invalid boundary line":  "--b\r\nContent-Disposition: form-data; name=a\r\n\r\nvalue\r\n--bc\r\n",
+		"header not terminated":  "--b\r\nContent-Disposition: form-data; name=a\r\n",
+		"too large header":       "--b\r\nX: " + strings.Repeat("a", 5000) + "\r\n\r\n\r\n--b--",
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, _ := NewReader(strings.NewReader(body), "b")
+			var err error
+			for err == nil {
+				var p *Part
+				if p, err = r.NextPart(); err == nil {
+					_, err = ioutil.ReadAll(p)
+				}
+			}
+			if httpErr, ok := err.(*http.HTTPError); !ok || httpErr.Status != 400 {
+				t.Errorf("Unexpected error: %v.", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package negotiation
+
+import (
+	"strings"
+
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+// Charset chooses the charset in offers most preferred by Accept-Charset (RFC 9110 12.5.2).
+// offers are charsets like "utf-8" in order of server preference.
+// Charsets not listed get weight of "*" if it exists.
+// Any offer is acceptable if request has no valid Accept-Charset.
+// Chosen charset should be set as charset parameter of Content-Type.
+func Charset(h header.Headers, offers []string) (Result, bool) {
+	result := Result{Vary: "accept-charset"}
+	// invalid charsets of Accept-Charset are disregarded
+	charsets, _ := h.GetAcceptCharsets()
+	best := 0.0
+	for _, offer := range offers {
+		q := 1.0
+		if len(charsets) != 0 {
+			q = charsetQuality(charsets, offer)
+		}
+		if q > best {
+			best = q
+			result.Value = offer
+		}
+	}
+	return result, best > 0
+}
+
+func charsetQuality(charsets []header.AcceptCharset, offer string) float64 {
+	wildcard := 0.0
+	for _, c := range charsets {
+		if strings.EqualFold(c.Charset, offer) {
+			return c.Weight
+		}
+		if c.Charset == "*" {
+			wildcard = c.Weight
+		}
+	}
+	return wildcard
+}
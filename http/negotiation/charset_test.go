@@ -0,0 +1,36 @@
+package negotiation
+
+import (
+	"testing"
+
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+func TestCharset(t *testing.T) {
+	offers := []string{"utf-8", "iso-8859-1"}
+	tests := []struct {
+		acceptCharset string
+		expected      string
+		ok            bool
+	}{
+		{"", "utf-8", true},
+		{"ISO-8859-1, utf-8;q=0.5", "iso-8859-1", true},
+		{"shift_jis", "", false},
+		{"shift_jis, *;q=0.1", "utf-8", true},
+		{"*, utf-8;q=0", "iso-8859-1", true},
+		{"utf-8;q=2, iso-8859-1;q=0.5", "iso-8859-1", true},
+	}
+	for _, tt := range tests {
+		h := header.Headers{}
+		if tt.acceptCharset != "" {
+			h.Add("ACCEPT-CHARSET", tt.acceptCharset)
+		}
+		result, ok := Charset(h, offers)
+		if result.Value != tt.expected || ok != tt.ok {
+			t.Errorf("Unexpected result: %v %v for %v", result.Value, ok, tt.acceptCharset)
+		}
+		if result.Vary != "accept-charset" {
+			t.Errorf("Unexpected vary: %v", result.Vary)
+		}
+	}
+}
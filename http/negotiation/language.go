@@ -0,0 +1,100 @@
+package negotiation
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+// Language chooses the language tag in offers most preferred by Accept-Language (RFC 9110 12.5.4).
+// offers are language tags like "en-US" in order of server preference.
+// Quality of each tag is given by the longest language range matching it by basic filtering.
+// Any offer is acceptable if request has no valid Accept-Language.
+// It returns false if no offer is acceptable. Server may respond default language instead of 406 then.
+func Language(h header.Headers, offers []string) (Result, bool) {
+	result := Result{Vary: "accept-language", Field: "Content-Language"}
+	// invalid ranges of Accept-Language are disregarded
+	ranges, _ := h.GetAcceptLanguages()
+	if len(ranges) == 0 {
+		if len(offers) == 0 {
+			return result, false
+		}
+		result.Value = offers[0]
+		return result, true
+	}
+	filtered := FilterLanguages(ranges, offers)
+	if len(filtered) == 0 {
+		return result, false
+	}
+	result.Value = filtered[0]
+	return result, true
+}
+
+// FilterLanguages returns tags matching ranges by basic filtering (RFC 4647 3.3.1) in order of preference.
+// A range matches a tag if it's same as the tag or prefix of the tag followed by "-". "*" matches any tag.
+// If a tag is matched by some ranges, the longest one gives its quality. Tags with q=0 are excluded.
+func FilterLanguages(ranges []header.AcceptLanguage, tags []string) []string {
+	type weighted struct {
+		tag    string
+		weight float64
+	}
+	matched := []weighted{}
+	for _, tag := range tags {
+		weight := 0.0
+		longest := -1
+		for _, r := range ranges {
+			if basicFilter(r.Range, tag) && len(r.Range) > longest {
+				longest = len(r.Range)
+				weight = r.Weight
+			}
+		}
+		if weight > 0 {
+			matched = append(matched, weighted{tag, weight})
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].weight > matched[j].weight
+	})
+	result := []string{}
+	for _, m := range matched {
+		result = append(result, m.tag)
+	}
+	return result
+}
+
+func basicFilter(r string, tag string) bool {
+	if r == "*" {
+		return true
+	}
+	if len(tag) < len(r) || !strings.EqualFold(tag[:len(r)], r) {
+		return false
+	}
+	return len(tag) == len(r) || tag[len(r)] == '-'
+}
+
+// LookupLanguage returns the tag best matching ranges by lookup (RFC 4647 3.4).
+// For each range in order of weight, the range is shortened from the end until it's same as a tag.
+// "*" and ranges with q=0 are skipped. It returns def if nothing is found.
+func LookupLanguage(ranges []header.AcceptLanguage, tags []string, def string) string {
+	for _, r := range ranges {
+		if r.Range == "*" || r.Weight == 0 {
+			continue
+		}
+		subtags := strings.Split(r.Range, "-")
+		for len(subtags) != 0 {
+			candidate := strings.Join(subtags, "-")
+			for _, tag := range tags {
+				if strings.EqualFold(tag, candidate) {
+					return tag
+				}
+			}
+			subtags = subtags[:len(subtags)-1]
+			// single character subtag like "x" of private use can't be the last
+			if len(subtags) != 0 && len(subtags[len(subtags)-1]) == 1 {
+				subtags = subtags[:len(subtags)-1]
+			}
+		}
+	}
+	return def
+}
@@ -0,0 +1,84 @@
+package negotiation
+
+import (
+	"testing"
+
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+func TestLanguage(t *testing.T) {
+	offers := []string{"en-US", "en-GB", "ja", "de-CH-1996"}
+	tests := []struct {
+		acceptLanguage string
+		expected       string
+		ok             bool
+	}{
+		{"", "en-US", true},
+		{"ja, en;q=0.5", "ja", true},
+		{"en", "en-US", true},
+		{"en-gb, en;q=0.5", "en-GB", true},
+		{"en, en-US;q=0", "en-GB", true},
+		{"de", "de-CH-1996", true},
+		{"de-CH", "de-CH-1996", true},
+		{"de-C", "", false},
+		{"en_US, ja;q=0.5", "ja", true},
+		{"fr", "", false},
+		{"fr, *;q=0.1", "en-US", true},
+		{"*, ja;q=0", "en-US", true},
+		{"*;q=0", "", false},
+	}
+	for _, tt := range tests {
+		h := header.Headers{}
+		if tt.acceptLanguage != "" {
+			h.Add("ACCEPT-LANGUAGE", tt.acceptLanguage)
+		}
+		result, ok := Language(h, offers)
+		if result.Value != tt.expected || ok != tt.ok {
+			t.Errorf("Unexpected result: %v %v for %v", result.Value, ok, tt.acceptLanguage)
+		}
+	}
+}
+
+func TestLookupLanguage(t *testing.T) {
+	tags := []string{"en", "zh-Hant", "de-CH"}
+	tests := []struct {
+		acceptLanguage string
+		expected       string
+	}{
+		{"en-US", "en"},
+		{"zh-Hant-CN-x-private1-private2", "zh-Hant"},
+		{"de-CH-1996", "de-CH"},
+		{"de", "default"},
+		{"fr, de-CH-x-a;q=0.5", "de-CH"},
+		{"*, en;q=0", "default"},
+	}
+	for _, tt := range tests {
+		ranges, err := header.ParseAcceptLanguage(tt.acceptLanguage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := LookupLanguage(ranges, tags, "default")
+		if actual != tt.expected {
+			t.Errorf("Unexpected result: %v for %v", actual, tt.acceptLanguage)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	req := header.NewHeaders(header.Header{FieldName: "ACCEPT", FieldValue: "text/html"}, header.Header{FieldName: "ACCEPT-LANGUAGE", FieldValue: "ja"})
+	contentType, _ := ContentType(req, []string{"text/html;charset=utf-8"})
+	language, _ := Language(req, []string{"en", "ja"})
+
+	h := header.Headers{}
+	h.Set("Vary", "accept-encoding")
+	Apply(&h, contentType, language)
+	if v := h.Get("Vary"); v != "accept-encoding, accept, accept-language" {
+		t.Errorf("Unexpected Vary: %v", v)
+	}
+	if v := h.Get("Content-Language"); v != "ja" {
+		t.Errorf("Unexpected Content-Language: %v", v)
+	}
+	if v := h.Get("Content-Type"); v != "text/html;charset=utf-8" {
+		t.Errorf("Unexpected Content-Type: %v", v)
+	}
+}
@@ -0,0 +1,109 @@
+// Package negotiation implements proactive content negotiation (RFC 9110 12.1).
+//
+// Handlers pass representations they can respond in order of their preference,
+// and the most preferred one by request headers is chosen.
+package negotiation
+
+import (
+	"strings"
+
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+// Result is a negotiated representation.
+type Result struct {
+	// Value is the chosen offer.
+	Value string
+	// Vary is request field name which the choice depends on. It should be added to Vary header.
+	Vary string
+	// Field is response field name describing the choice like Content-Language.
+	// It's empty if the choice is described by other field.
+	Field string
+}
+
+// Apply adds Vary of results to response headers h and sets their fields.
+func Apply(h *header.Headers, results ...Result) {
+	for _, r := range results {
+		h.AddVary(r.Vary)
+		if r.Field != "" && r.Value != "" {
+			h.Set(r.Field, r.Value)
+		}
+	}
+}
+
+// ContentType chooses the offer most preferred by Accept (RFC 9110 12.5.1).
+// offers are media types like "application/json" or "text/plain;charset=utf-8" in order of server preference.
+// Quality of each offer is given by the most specific media range matching it.
+// Offers having same quality are chosen by order of offers.
+// Any offer is acceptable if request has no valid Accept.
+// It returns false if no offer is acceptable.
+func ContentType(h header.Headers, offers []string) (Result, bool) {
+	result := Result{Vary: "accept", Field: "Content-Type"}
+	// invalid media ranges of Accept are disregarded
+	accepts, _ := h.GetAccept()
+	best := 0.0
+	for _, offer := range offers {
+		q := 1.0
+		if len(accepts) != 0 {
+			q = mediaTypeQuality(accepts, offer)
+		}
+		if q > best {
+			best = q
+			result.Value = offer
+		}
+	}
+	return result, best > 0
+}
+
+// mediaTypeQuality returns q of the most specific media range matching offer. 0 means not acceptable.
+func mediaTypeQuality(accepts []header.Accept, offer string) float64 {
+	parsed, err := header.ParseAccept(offer)
+	if err != nil || len(parsed) == 0 {
+		return 0
+	}
+	o := parsed[0]
+
+	q := 0.0
+	specificity := -1
+	for _, a := range accepts {
+		s, ok := match(a, o)
+		if ok && s > specificity {
+			specificity = s
+			q = a.Weight()
+		}
+	}
+	return q
+}
+
+// match returns specificity of media range a if it matches offer o.
+// type/subtype with parameters > type/subtype > type/* > */*.
+func match(a header.Accept, o header.Accept) (int, bool) {
+	if a.Type == "*" {
+		return 0, a.SubType == "*"
+	}
+	if !strings.EqualFold(a.Type, o.Type) {
+		return 0, false
+	}
+	if a.SubType == "*" {
+		return 1, true
+	}
+	if !strings.EqualFold(a.SubType, o.SubType) {
+		return 0, false
+	}
+	params := a.MediaParameters()
+	for _, p := range params {
+		if !hasParameter(o.MediaParameters(), p) {
+			return 0, false
+		}
+	}
+	return 2 + len(params), true
+}
+
+func hasParameter(params []header.AcceptParameter, p header.AcceptParameter) bool {
+	for _, v := range params {
+		if strings.EqualFold(v.Key, p.Key) && strings.EqualFold(strings.Trim(v.Value, `"`), strings.Trim(p.Value, `"`)) {
+			return true
+		}
+	}
+	return false
+}
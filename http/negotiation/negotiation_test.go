@@ -0,0 +1,51 @@
+package negotiation
+
+import (
+	"testing"
+
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+func TestContentType(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/plain;charset=utf-8", "text/html;level=1"}
+	tests := []struct {
+		accept   string
+		expected string
+		ok       bool
+	}{
+		{"", "application/json", true},
+		{"*/*", "application/json", true},
+		{"application/xml", "application/xml", true},
+		{"application/*;q=0.5, application/xml", "application/xml", true},
+		{"application/*, application/json;q=0", "application/xml", true},
+		{"*/*;q=0.1, text/*", "text/plain;charset=utf-8", true},
+		{"text/*, text/plain;charset=iso-8859-1;q=0, text/html;q=0.5", "text/plain;charset=utf-8", true},
+		{"text/plain;charset=UTF-8, */*;q=0.5", "text/plain;charset=utf-8", true},
+		{"text/html;level=2, text/html;level=1;q=0.8", "text/html;level=1", true},
+		{"image/png", "", false},
+		{"*/*;q=0", "", false},
+		{"APPLICATION/XML", "application/xml", true},
+		{"invalid, application/xml;q=0.5", "application/xml", true},
+	}
+	for _, tt := range tests {
+		h := header.Headers{}
+		if tt.accept != "" {
+			h.Add("ACCEPT", tt.accept)
+		}
+		result, ok := ContentType(h, offers)
+		if result.Value != tt.expected || ok != tt.ok {
+			t.Errorf("Unexpected result: %v %v for %v", result.Value, ok, tt.accept)
+		}
+		if result.Vary != "accept" {
+			t.Errorf("Unexpected vary: %v", result.Vary)
+		}
+	}
+}
+
+func TestContentType_MultipleHeaders(t *testing.T) {
+	h := header.NewHeaders(header.Header{FieldName: "ACCEPT", FieldValue: "text/html;q=0.5"}, header.Header{FieldName: "ACCEPT", FieldValue: "application/xml"})
+	result, ok := ContentType(h, []string{"text/html", "application/xml"})
+	if !ok || result.Value != "application/xml" {
+		t.Errorf("Unexpected result: %v %v", result.Value, ok)
+	}
+}
@@ -0,0 +1,133 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/inabajunmr/http11server/http"
+)
+
+// body is request body read lazily from connection.
+type body struct {
+	// wire reads framed body from connection.
+	wire io.Reader
+	// decoded reads wire with transfer codings and content codings decoded.
+	decoded io.Reader
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	return b.decoded.Read(p)
+}
+
+func (b *body) Close() error {
+	if c, ok := b.decoded.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func emptyBody() io.ReadCloser {
+	return io.NopCloser(strings.NewReader(""))
+}
+
+// contentLengthReader reads body framed by Content-Length.
+type contentLengthReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (r *contentLengthReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if err == io.EOF && r.remaining > 0 {
+		return n, &http.HTTPError{Msg: "Content-Length and real body size are different.", Status: 400}
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// lazyReader opens reader at first Read.
+type lazyReader struct {
+	open   func() (io.Reader, error)
+	reader io.Reader
+	err    error
+}
+
+func (r *lazyReader) Read(p []byte) (int, error) {
+	if r.reader == nil && r.err == nil {
+		r.reader, r.err = r.open()
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.reader.Read(p)
+}
+
+func (r *lazyReader) Close() error {
+	if c, ok := r.reader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// limitReader returns tooLarge when reader has more than remaining bytes.
+type limitReader struct {
+	reader    io.Reader
+	remaining int64
+	tooLarge  error
+	closer    io.Closer
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.reader.Read(p)
+	if int64(n) > r.remaining {
+		return 0, r.tooLarge
+	}
+	r.remaining -= int64(n)
+	return n, err
+}
+
+func (r *limitReader) Close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// decodeErrorReader responds 400 for broken encoded body.
+// Errors from connection and HTTPError like too large body are returned as they are.
+type decodeErrorReader struct {
+	reader io.ReadCloser
+	name   string
+}
+
+func (r *decodeErrorReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+	var httpErr *http.HTTPError
+	var netErr net.Error
+	if errors.As(err, &httpErr) || errors.As(err, &netErr) {
+		return n, err
+	}
+	return n, &http.HTTPError{Msg: fmt.Sprintf("Invalid %v body.", r.name), Status: 400}
+}
+
+func (r *decodeErrorReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,284 @@
+package request
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+// ChunkExtension is chunk-ext of chunked body.
+type ChunkExtension struct {
+	// Chunk is index of chunk having this extension. Last chunk's index is number of data chunks.
+	Chunk int
+	Name  string
+	// Value is unquoted value. It's empty if extension has no value.
+	Value string
+}
+
+// ParseChunkSize parses chunk-size and chunk-ext line.
+//
+//	chunk-size [ BWS ";" BWS chunk-ext-name [ BWS "=" BWS chunk-ext-val ] ]*
+func ParseChunkSize(line string) (int64, []ChunkExtension, error) {
+	i := 0
+	for i < len(line) && isHexDigit(line[i]) {
+		i++
+	}
+	if i == 0 {
+		return 0, nil, &http.HTTPError{Msg: fmt.Sprintf("Invalid chunk size: %v.", line), Status: 400}
+	}
+
+	var size int64
+	for _, c := range line[:i] {
+		if size > (1<<63-1)>>4 {
+			return 0, nil, &http.HTTPError{Msg: fmt.Sprintf("Chunk size is too large: %v.", line), Status: 400}
+		}
+		size = size<<4 | int64(hexValue(byte(c)))
+	}
+
+	exts, err := parseChunkExtensions(line[i:])
+	if err != nil {
+		return 0, nil, err
+	}
+	return size, exts, nil
+}
+
+func parseChunkExtensions(s string) ([]ChunkExtension, error) {
+	invalid := &http.HTTPError{Msg: fmt.Sprintf("Invalid chunk extension: %v.", s), Status: 400}
+	exts := []ChunkExtension{}
+	i := 0
+	for {
+		i = skipBWS(s, i)
+		if i == len(s) {
+			return exts, nil
+		}
+		if s[i] != ';' {
+			return nil, invalid
+		}
+		i = skipBWS(s, i+1)
+		start := i
+		for i < len(s) && header.IsTokenChar(s[i]) {
+			i++
+		}
+		if start == i {
+			return nil, invalid
+		}
+		ext := ChunkExtension{Name: s[start:i]}
+
+		j := skipBWS(s, i)
+		if j < len(s) && s[j] == '=' {
+			i = skipBWS(s, j+1)
+			if i < len(s) && s[i] == '"' {
+				v, n, ok := header.ReadQuotedString(s[i:])
+				if !ok {
+					return nil, invalid
+				}
+				ext.Value = v
+				i += n
+			} else {
+				start := i
+				for i < len(s) && header.IsTokenChar(s[i]) {
+					i++
+				}
+				if start == i {
+					return nil, invalid
+				}
+				ext.Value = s[start:i]
+			}
+		}
+		exts = append(exts, ext)
+	}
+}
+
+func skipBWS(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// chunkedReader reads body framed by chunked transfer coding.
+// Any malformed chunk is 400 and connection can't be used after that.
+type chunkedReader struct {
+	reader *bufio.Reader
+	// remaining is unread size of current chunk.
+	remaining int64
+	chunks    int
+	done      bool
+	total     int64
+	max       int64
+	// headers declares trailer fields by Trailer header.
+	headers    header.Headers
+	trailers   *header.Headers
+	extensions *[]ChunkExtension
+	// extensionCount and extensionBytes are total of extensions of all chunks.
+	extensionCount int
+	extensionBytes int
+	limits         Limits
+	// err is kept because rest of body can't be read after error.
+	err error
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.read(p)
+	if err == io.EOF && !r.done {
+		err = &http.HTTPError{Msg: "Chunked body is terminated unexpectedly.", Status: 400}
+	}
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *chunkedReader) read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	if r.remaining == 0 {
+		limits := r.limits.orDefault()
+		line, err := readRawLineLimit(r.reader, limits.MaxHeaderFieldBytes)
+		if err == errLineTooLong {
+			return 0, &http.HTTPError{Msg: "Chunk size line is too long.", Status: 400}
+		}
+		if err != nil {
+			return 0, err
+		}
+		// size lines are a part of body on the wire, so they count toward max with their CRLF
+		r.total += int64(len(line))
+		if r.total > r.max {
+			return 0, errBodyTooLarge
+		}
+		// bare LF is not allowed since framing must not differ from other recipients
+		if !bytes.HasSuffix(line, []byte("\r\n")) {
+			return 0, &http.HTTPError{Msg: "Chunk size line is not terminated by CRLF.", Status: 400}
+		}
+		chunkSize, exts, err := ParseChunkSize(string(line[:len(line)-2]))
+		if err != nil {
+			return 0, err
+		}
+		if err := r.addExtensions(exts, limits); err != nil {
+			return 0, err
+		}
+		if chunkSize == 0 {
+			if err := r.readTrailers(); err != nil {
+				return 0, err
+			}
+			r.done = true
+			return 0, io.EOF
+		}
+		// compared before addition since huge chunk size overflows total
+		if chunkSize > r.max-r.total {
+			return 0, errBodyTooLarge
+		}
+		r.total += chunkSize
+		r.remaining = chunkSize
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if err != nil {
+		return n, err
+	}
+	if r.remaining == 0 {
+		r.chunks++
+		if err := r.readCRLF(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// addExtensions keeps extensions of current chunk.
+// Extensions of all chunks are limited by limits.MaxChunkExtensions and limits.MaxChunkExtensionBytes
+// even if they are not kept since they may be sent repeatedly to occupy the server.
+func (r *chunkedReader) addExtensions(exts []ChunkExtension, limits Limits) error {
+	for _, ext := range exts {
+		r.extensionCount++
+		r.extensionBytes += len(ext.Name) + len(ext.Value)
+		if r.extensionCount > limits.MaxChunkExtensions {
+			return &http.HTTPError{Msg: "Too many chunk extensions.", Status: 400}
+		}
+		if r.extensionBytes > limits.MaxChunkExtensionBytes {
+			return &http.HTTPError{Msg: "Chunk extensions are too large.", Status: 413}
+		}
+		if r.extensions != nil {
+			ext.Chunk = r.chunks
+			*r.extensions = append(*r.extensions, ext)
+		}
+	}
+	return nil
+}
+
+// readCRLF reads CRLF after chunk data.
+func (r *chunkedReader) readCRLF() error {
+	b, err := r.reader.Peek(2)
+	if err != nil {
+		return err
+	}
+	if b[0] != '\r' || b[1] != '\n' {
+		return &http.HTTPError{Msg: "Chunk data is not terminated by CRLF.", Status: 400}
+	}
+	_, err = r.reader.Discard(2)
+	return err
+}
+
+// readTrailers reads trailer section after last chunk.
+// Trailer field must be declared by Trailer header and must not be forbidden field.
+func (r *chunkedReader) readTrailers() error {
+	limits := r.limits.orDefault()
+	trailers := header.Headers{}
+	for {
+		line, err := readLineLimit(r.reader, limits.MaxHeaderFieldBytes)
+		if err == errLineTooLong {
+			return &http.HTTPError{Msg: "Trailer field is too long.", Status: 431}
+		}
+		if err != nil {
+			return err
+		}
+		if *line == "" {
+			break
+		}
+		t, err := header.ParseHeader(*line)
+		if err != nil {
+			return &http.HTTPError{Msg: fmt.Sprintf("Invalid trailer field: %v.", *line), Status: 400}
+		}
+		if header.IsForbiddenTrailer(t.FieldName) {
+			return &http.HTTPError{Msg: fmt.Sprintf("%v is not allowed in trailer.", t.FieldName), Status: 400}
+		}
+		if !r.headers.IsDeclaredTrailer(t.FieldName) {
+			return &http.HTTPError{Msg: fmt.Sprintf("%v is not declared by Trailer header.", t.FieldName), Status: 400}
+		}
+		trailers.Add(t.FieldName, t.FieldValue)
+		if trailers.Len() > limits.MaxHeaderCount {
+			return &http.HTTPError{Msg: "Too many trailer fields.", Status: 431}
+		}
+	}
+	if r.trailers != nil {
+		*r.trailers = trailers
+	}
+	return nil
+}
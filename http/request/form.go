@@ -0,0 +1,127 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/multipart"
+)
+
+// Query returns parameters of query component of request target.
+// It's parsed at first call and the result is cached.
+func (r *Request) Query() (url.Values, error) {
+	if r.query == nil && r.queryErr == nil {
+		r.query, r.queryErr = parseURLEncoded(r.StartLine.Target.RawQuery, r.limits.orDefault().MaxFormParams, "Query")
+	}
+	return r.query, r.queryErr
+}
+
+// Form returns parameters of application/x-www-form-urlencoded body.
+// It's empty for other media types. It's parsed at first call and the result is cached.
+// Form reads whole body, so Body is replaced by reader of read bytes and can be read again.
+func (r *Request) Form() (url.Values, error) {
+	if r.form == nil && r.formErr == nil {
+		r.form, r.formErr = r.parseForm()
+	}
+	return r.form, r.formErr
+}
+
+func (r *Request) parseForm() (url.Values, error) {
+	if r.Body == nil || r.ContentType().MediaType() != "application/x-www-form-urlencoded" {
+		return url.Values{}, nil
+	}
+	limits := r.limits.orDefault()
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, limits.MaxFormBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limits.MaxFormBytes {
+		return nil, &http.HTTPError{Msg: "Form is too large.", Status: 413}
+	}
+	r.Body = &bufferedBody{Reader: bytes.NewReader(b), Closer: r.Body}
+	return parseURLEncoded(string(b), limits.MaxFormParams, "Form")
+}
+
+// bufferedBody reads body already read from connection. Close closes original body.
+type bufferedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// parseURLEncoded parses name=value pairs separated by "&".
+// "+" is decoded as space and percent-encoded octets are decoded in names and values.
+// name is used for error messages.
+func parseURLEncoded(s string, maxParams int, name string) (url.Values, error) {
+	values := url.Values{}
+	count := 0
+	for _, pair := range strings.Split(s, "&") {
+		if pair == "" {
+			continue
+		}
+		count++
+		if count > maxParams {
+			return nil, &http.HTTPError{Msg: fmt.Sprintf("%v has too many parameters.", name), Status: 400}
+		}
+		k, v := pair, ""
+		if i := strings.IndexByte(pair, '='); i != -1 {
+			k, v = pair[:i], pair[i+1:]
+		}
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			return nil, &http.HTTPError{Msg: fmt.Sprintf("%v parameter %v is invalid.", name, k), Status: 400}
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return nil, &http.HTTPError{Msg: fmt.Sprintf("%v parameter %v is invalid.", name, k), Status: 400}
+		}
+		values.Add(key, value)
+	}
+	return values, nil
+}
+
+// MultipartReader returns reader of multipart/form-data body to read parts one by one.
+func (r *Request) MultipartReader() (*multipart.Reader, error) {
+	ct := r.ContentType()
+	if r.Body == nil || ct.MediaType() != "multipart/form-data" {
+		return nil, &http.HTTPError{Msg: "Content-Type is not multipart/form-data.", Status: 415}
+	}
+	boundary, _ := ct.Parameter("boundary")
+	return multipart.NewReader(r.Body, boundary)
+}
+
+// MultipartForm reads all parts of multipart/form-data body.
+// Large file parts are stored to temp files and they are removed by Cleanup.
+// It's read at first call and the result is cached.
+func (r *Request) MultipartForm() (*multipart.Form, error) {
+	if r.multipartForm == nil && r.multipartErr == nil {
+		r.multipartForm, r.multipartErr = r.readMultipartForm()
+	}
+	return r.multipartForm, r.multipartErr
+}
+
+func (r *Request) readMultipartForm() (*multipart.Form, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	limits := r.limits.orDefault()
+	form, err := multipart.ReadForm(mr, limits.MaxMultipartMemory, limits.MaxFormParams)
+	if err != nil {
+		form.RemoveAll()
+		return nil, err
+	}
+	return form, nil
+}
+
+// Cleanup removes temp files of MultipartForm. Server calls it after response.
+func (r *Request) Cleanup() error {
+	if r.multipartForm == nil {
+		return nil
+	}
+	return r.multipartForm.RemoveAll()
+}
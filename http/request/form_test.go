@@ -0,0 +1,183 @@
+package request
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inabajunmr/http11server/http"
+)
+
+func TestParseURLEncoded(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected map[string][]string
+	}{
+		{value: "", expected: map[string][]string{}},
+		{value: "a=1&b=2&a=3", expected: map[string][]string{"a": {"1", "3"}, "b": {"2"}}},
+		{value: "a+b=c+d%2Be", expected: map[string][]string{"a b": {"c d+e"}}},
+		{value: "%E3%81%82=%26%3D", expected: map[string][]string{"あ": {"&="}}},
+		{value: "a&b=&&=c", expected: map[string][]string{"a": {""}, "b": {""}, "": {"c"}}},
+		{value: "a=1;b=2", expected: map[string][]string{"a": {"1;b=2"}}},
+	}
+	for _, tt := range tests {
+		actual, err := parseURLEncoded(tt.value, 10, "Query")
+		if err != nil {
+			t.Errorf("Unexpected error: %v for %v.", err, tt.value)
+			continue
+		}
+		if !reflect.DeepEqual(map[string][]string(actual), tt.expected) {
+			t.Errorf("Unexpected values: %v for %v.", actual, tt.value)
+		}
+	}
+}
+
+func TestParseURLEncoded_Invalid(t *testing.T) {
+	for _, value := range []string{"a=%zz", "%=1", "a=%4", "a=1&b=2&c=3"} {
+		_, err := parseURLEncoded(value, 2, "Query")
+		if httpErr, ok := err.(*http.HTTPError); !ok || httpErr.Status != 400 {
+			t.Errorf("Unexpected error: %v for %v.", err, value)
+		}
+	}
+}
+
+func TestRequest_Query(t *testing.T) {
+	request := "GET /search?q=go+http&page=2&q=%2A HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	req, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := req.Query()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(query["q"], []string{"go http", "*"}) || query.Get("page") != "2" {
+		t.Errorf("Unexpected query: %v.", query)
+	}
+}
+
+func TestRequest_Form(t *testing.T) {
+	request := "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/x-www-form-urlencoded; charset=utf-8\r\n" +
+		"Content-Length: 17\r\n\r\nname=a+b&x=%21%21"
+	req, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := req.Form()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Get("name") != "a b" || form.Get("x") != "!!" {
+		t.Errorf("Unexpected form: %v.", form)
+	}
+
+	// body can be read after Form
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "name=a+b&x=%21%21" {
+		t.Errorf("Unexpected body: %v.", string(b))
+	}
+}
+
+func TestRequest_Form_OtherMediaType(t *testing.T) {
+	request := "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: text/plain\r\nContent-Length: 3\r\n\r\na=1"
+	req, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := req.Form()
+	if err != nil || len(form) != 0 {
+		t.Errorf("Unexpected form: %v, %v.", form, err)
+	}
+	b, _ := ioutil.ReadAll(req.Body)
+	if string(b) != "a=1" {
+		t.Errorf("Unexpected body: %v.", string(b))
+	}
+}
+
+func TestRequest_Form_Limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		body   string
+		status int
+	}{
+		{name: "bytes", limits: Limits{MaxFormBytes: 5}, body: "a=123", status: 0},
+		{name: "too many bytes", limits: Limits{MaxFormBytes: 5}, body: "a=1234", status: 413},
+		{name: "too many params", limits: Limits{MaxFormParams: 2}, body: "a&b&c", status: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/x-www-form-urlencoded\r\n" +
+				"Transfer-Encoding: chunked\r\n\r\n" + fmt.Sprintf("%x\r\n%v\r\n0\r\n\r\n", len(tt.body), tt.body)
+			reader := bufio.NewReader(strings.NewReader(request))
+			req, err := ParseRequestHead(reader, tt.limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ReadBody(reader, req, tt.limits); err != nil {
+				t.Fatal(err)
+			}
+			_, err = req.Form()
+			if tt.status == 0 {
+				if err != nil {
+					t.Errorf("Unexpected error: %v.", err)
+				}
+				return
+			}
+			if httpErr, ok := err.(*http.HTTPError); !ok || httpErr.Status != tt.status {
+				t.Errorf("Unexpected error: %v.", err)
+			}
+		})
+	}
+}
+
+func TestRequest_MultipartForm(t *testing.T) {
+	body := "--b\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n1\r\n" +
+		"--b\r\nContent-Disposition: form-data; name=\"f\"; filename=\"f.bin\"\r\nContent-Type: application/octet-stream\r\n\r\n" +
+		"0123456789\r\n--b--\r\n"
+	request := fmt.Sprintf("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: multipart/form-data; boundary=b\r\n"+
+		"Content-Length: %v\r\n\r\n%v", len(body), body)
+	reader := bufio.NewReader(strings.NewReader(request))
+	limits := Limits{MaxMultipartMemory: 5}
+	req, err := ParseRequestHead(reader, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadBody(reader, req, limits); err != nil {
+		t.Fatal(err)
+	}
+	defer req.Cleanup()
+
+	form, err := req.MultipartForm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := form.Value("a"); v != "1" {
+		t.Errorf("Unexpected value: %v.", v)
+	}
+	files := form.Files("f")
+	if len(files) != 1 || files[0].FileName != "f.bin" || files[0].Size != 10 {
+		t.Errorf("Unexpected files: %v.", files)
+	}
+	if err := req.Cleanup(); err != nil {
+		t.Errorf("Unexpected error: %v.", err)
+	}
+}
+
+func TestRequest_MultipartReader_NotMultipart(t *testing.T) {
+	request := "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: text/plain\r\nContent-Length: 3\r\n\r\na=1"
+	req, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = req.MultipartForm()
+	if httpErr, ok := err.(*http.HTTPError); !ok || httpErr.Status != 415 {
+		t.Errorf("Unexpected error: %v.", err)
+	}
+}
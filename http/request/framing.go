@@ -0,0 +1,92 @@
+package request
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/header"
+)
+
+// validateFraming rejects requests whose body length can be read in more than one way.
+// If front proxies and this server disagree about where a body ends, the rest can be
+// treated as another request (request smuggling). See RFC 9112 6.1 and 6.3.
+// Identical Content-Length values are normalized to a single header.
+func validateFraming(headers *header.Headers) error {
+	te := headers.Values("Transfer-Encoding")
+	cl := headers.Values("Content-Length")
+	if len(te) != 0 && len(cl) != 0 {
+		return &http.HTTPError{Msg: "Both Transfer-Encoding and Content-Length are not allowed.", Status: 400}
+	}
+	if len(te) != 0 {
+		tes, err := headers.GetTransferEncodings()
+		if err != nil {
+			return &http.HTTPError{Msg: fmt.Sprintf("Transfer-Encoding is invalid: %v", err), Status: 400}
+		}
+		return validateTransferEncoding(tes)
+	}
+	if len(cl) != 0 {
+		return normalizeContentLength(headers, cl)
+	}
+	return nil
+}
+
+func validateTransferEncoding(tes []header.TransferEncoding) error {
+	if len(tes) == 0 {
+		return &http.HTTPError{Msg: "Transfer-Encoding is empty.", Status: 400}
+	}
+	for i, te := range tes {
+		if te == header.TRANSFER_ENCODING_UNKNOWN {
+			return &http.HTTPError{Msg: "Transfer-Encoding is not implemented.", Status: 501}
+		}
+		if te == header.TRANSFER_ENCODING_CHUNKED && i != len(tes)-1 {
+			return &http.HTTPError{Msg: "chunked must be the final transfer coding.", Status: 400}
+		}
+	}
+	if tes[len(tes)-1] != header.TRANSFER_ENCODING_CHUNKED {
+		// length of request body can't be determined without chunked
+		return &http.HTTPError{Msg: "chunked must be the final transfer coding.", Status: 400}
+	}
+	return nil
+}
+
+func normalizeContentLength(headers *header.Headers, values []string) error {
+	value := ""
+	for _, v := range values {
+		for _, l := range strings.Split(v, ",") {
+			l = strings.TrimSpace(l)
+			if !isDigits(l) {
+				return &http.HTTPError{Msg: fmt.Sprintf("Content-Length:%v is invalid.", l), Status: 400}
+			}
+			l = strings.TrimLeft(l, "0")
+			if l == "" {
+				l = "0"
+			}
+			if value != "" && value != l {
+				return &http.HTTPError{Msg: "Multiple Content-Length is not allowed.", Status: 400}
+			}
+			value = l
+		}
+	}
+	if len(value) > 18 {
+		// larger than any limit and may overflow int64
+		return errBodyTooLarge
+	}
+	if len(values) != 1 || values[0] != value {
+		headers.Set("Content-Length", value)
+	}
+	return nil
+}
+
+// Content-Length = 1*DIGIT
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
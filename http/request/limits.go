@@ -0,0 +1,83 @@
+package request
+
+// Limits restricts size of request to read. 0 means default value.
+type Limits struct {
+	// MaxRequestLineBytes limits request line length. Exceeding it responds 414.
+	MaxRequestLineBytes int
+	// MaxHeaderFieldBytes limits length of each header field line. Exceeding it responds 431.
+	MaxHeaderFieldBytes int
+	// MaxHeaderCount limits number of header fields. Exceeding it responds 431.
+	MaxHeaderCount int
+	// MaxHeaderBytes limits total length of header field lines. Exceeding it responds 431.
+	MaxHeaderBytes int
+	// MaxBodyBytes limits length of body on the wire. Exceeding it responds 413.
+	MaxBodyBytes int64
+	// MaxDecodedBodyBytes limits length of body after decoding Content-Encoding and Transfer-Encoding.
+	// Exceeding it responds 413.
+	MaxDecodedBodyBytes int64
+	// MaxChunkExtensions limits number of chunk extensions of all chunks. Exceeding it responds 400.
+	MaxChunkExtensions int
+	// MaxChunkExtensionBytes limits total length of names and values of chunk extensions of all chunks.
+	// Exceeding it responds 413.
+	MaxChunkExtensionBytes int
+	// MaxFormBytes limits length of application/x-www-form-urlencoded body read by Request.Form.
+	// Exceeding it responds 413.
+	MaxFormBytes int64
+	// MaxFormParams limits number of parameters of query and form and parts of multipart form each.
+	// Exceeding it responds 400.
+	MaxFormParams int
+	// MaxMultipartMemory limits total size of multipart parts kept in memory by Request.MultipartForm.
+	// File parts exceeding it are stored to temp files and other parts exceeding it responds 413.
+	MaxMultipartMemory int64
+}
+
+var DefaultLimits = Limits{
+	MaxRequestLineBytes:    8 * 1024,
+	MaxHeaderFieldBytes:    8 * 1024,
+	MaxHeaderCount:         100,
+	MaxHeaderBytes:         64 * 1024,
+	MaxBodyBytes:           10 * 1024 * 1024,
+	MaxDecodedBodyBytes:    10 * 1024 * 1024,
+	MaxChunkExtensions:     1000,
+	MaxChunkExtensionBytes: 64 * 1024,
+	MaxFormBytes:           1024 * 1024,
+	MaxFormParams:          1000,
+	MaxMultipartMemory:     1024 * 1024,
+}
+
+func (l Limits) orDefault() Limits {
+	if l.MaxRequestLineBytes == 0 {
+		l.MaxRequestLineBytes = DefaultLimits.MaxRequestLineBytes
+	}
+	if l.MaxHeaderFieldBytes == 0 {
+		l.MaxHeaderFieldBytes = DefaultLimits.MaxHeaderFieldBytes
+	}
+	if l.MaxHeaderCount == 0 {
+		l.MaxHeaderCount = DefaultLimits.MaxHeaderCount
+	}
+	if l.MaxHeaderBytes == 0 {
+		l.MaxHeaderBytes = DefaultLimits.MaxHeaderBytes
+	}
+	if l.MaxBodyBytes == 0 {
+		l.MaxBodyBytes = DefaultLimits.MaxBodyBytes
+	}
+	if l.MaxDecodedBodyBytes == 0 {
+		l.MaxDecodedBodyBytes = DefaultLimits.MaxDecodedBodyBytes
+	}
+	if l.MaxChunkExtensions == 0 {
+		l.MaxChunkExtensions = DefaultLimits.MaxChunkExtensions
+	}
+	if l.MaxChunkExtensionBytes == 0 {
+		l.MaxChunkExtensionBytes = DefaultLimits.MaxChunkExtensionBytes
+	}
+	if l.MaxFormBytes == 0 {
+		l.MaxFormBytes = DefaultLimits.MaxFormBytes
+	}
+	if l.MaxFormParams == 0 {
+		l.MaxFormParams = DefaultLimits.MaxFormParams
+	}
+	if l.MaxMultipartMemory == 0 {
+		l.MaxMultipartMemory = DefaultLimits.MaxMultipartMemory
+	}
+	return l
+}
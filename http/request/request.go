@@ -3,28 +3,65 @@ package request
 import (
 	"bufio"
 	"bytes"
-	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	ghttp "net/http"
-	"strconv"
+	"net/url"
 	"strings"
 
 	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/coding"
 	"github.com/inabajunmr/http11server/http/header"
+	"github.com/inabajunmr/http11server/http/multipart"
 )
 
 type Request struct {
 	StartLine StartLine
 	Headers   header.Headers
-	Body      []byte
+	// Body is read lazily from connection. Server discards unread body after response.
+	Body io.ReadCloser
+	// PathParams is set by router from path parameters and wildcards of matched route.
+	PathParams map[string]string
+	// Trailers is trailer section of chunked body. It's set after Body is read to EOF.
+	Trailers header.Headers
+	// ChunkExtensions is chunk-ext of chunked body. It's set while Body is read.
+	ChunkExtensions []ChunkExtension
+	// Close means connection is closed after response.
+	// It's set by Connection: close header or server shutdown.
+	Close bool
+
+	body   *body
+	limits Limits
+	// query, form and multipartForm are cached by Query, Form and MultipartForm.
+	query         url.Values
+	queryErr      error
+	form          url.Values
+	formErr       error
+	multipartForm *multipart.Form
+	multipartErr  error
 }
 
 func ParseRequest(reader *bufio.Reader) (*Request, error) {
+	req, err := ParseRequestHead(reader, Limits{})
+	if err != nil {
+		return nil, err
+	}
+	if err := ReadBody(reader, req, Limits{}); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ParseRequestHead reads start line and headers. Body is not read yet.
+func ParseRequestHead(reader *bufio.Reader, limits Limits) (*Request, error) {
+	limits = limits.orDefault()
 
-	l, err := readLine(reader)
+	l, err := readLineLimit(reader, limits.MaxRequestLineBytes)
+	if err == errLineTooLong {
+		return nil, &http.HTTPError{Msg: "Request line is too long.", Status: 414}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -36,152 +73,255 @@ func ParseRequest(reader *bufio.Reader) (*Request, error) {
 	if err != nil {
 		return nil, err
 	}
-	headers, err := readHeaders(reader)
-	if err == io.EOF {
-		return &Request{StartLine: *startLine, Headers: *headers, Body: nil}, nil
+	headers, err := readHeaders(reader, limits)
+	if err != nil {
+		return nil, err
+	}
+	if err := headers.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateFraming(headers); err != nil {
+		return nil, err
 	}
-	if headers.Validate() != nil {
+	if err := reconcileHost(startLine.Target, headers); err != nil {
 		return nil, err
 	}
 
+	return &Request{StartLine: *startLine, Headers: *headers, Body: emptyBody(), Close: headers.IsConnectionClose(), limits: limits}, nil
+}
+
+// reconcileHost validates Host header.
+// For absolute-form, Host is replaced by authority of the target since
+// origin server must ignore received Host then (RFC 9112 3.2.2).
+func reconcileHost(target Target, headers *header.Headers) error {
+	host := headers.Get("Host")
+	if _, _, err := parseAuthority(host); err != nil {
+		return &http.HTTPError{Msg: fmt.Sprintf("Host:%v is invalid.", host), Status: 400}
+	}
+	if target.Form == TARGET_FORM_ABSOLUTE {
+		headers.Set("Host", target.Authority())
+	}
+	return nil
+}
+
+// ReadBody sets Body of req which reads reader lazily.
+// Content-Length larger than limits.MaxBodyBytes responds 413 here.
+// Other errors like larger chunked body or decoded body than limits are returned from Body.Read.
+func ReadBody(reader *bufio.Reader, req *Request, limits Limits) error {
+	limits = limits.orDefault()
+	wire, err := wireBody(reader, req, limits)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	body, err := readBody(reader, *headers)
+	decoded, err := decodeBody(wire, req.Headers, limits.MaxDecodedBodyBytes)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	req.body = &body{wire: wire, decoded: decoded}
+	req.Body = req.body
+	req.limits = limits
+	return nil
+}
 
-	return &Request{StartLine: *startLine, Headers: *headers, Body: body}, nil
+// ContentType returns parsed Content-Type. It's application/octet-stream if request doesn't have it.
+func (r *Request) ContentType() header.ContentType {
+	// it's validated by ParseRequestHead
+	ct, _ := r.Headers.GetContentType()
+	return ct
 }
 
-func readHeaders(reader *bufio.Reader) (*header.Headers, error) {
+// Cookies returns cookies of Cookie headers.
+func (r *Request) Cookies() []header.Cookie {
+	return r.Headers.GetCookies()
+}
+
+// Cookie returns the first cookie of name.
+func (r *Request) Cookie(name string) (header.Cookie, bool) {
+	for _, c := range r.Cookies() {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return header.Cookie{}, false
+}
+
+// Discard reads rest of body from connection so that next request can be read.
+// It returns error if more than max bytes are left or body is invalid.
+func (r *Request) Discard(max int64) error {
+	if r.body == nil {
+		return nil
+	}
+	n, err := io.Copy(ioutil.Discard, io.LimitReader(r.body.wire, max+1))
+	if err != nil {
+		return err
+	}
+	if n > max {
+		return errBodyTooLarge
+	}
+	return nil
+}
+
+func readHeaders(reader *bufio.Reader, limits Limits) (*header.Headers, error) {
 	headers := header.Headers{}
+	total := 0
 	for {
-		line, err := readLine(reader)
+		line, err := readLineLimit(reader, limits.MaxHeaderFieldBytes)
+		if err == errLineTooLong {
+			return nil, &http.HTTPError{Msg: "Header field is too long.", Status: 431}
+		}
 		if err == io.EOF {
-			return &headers, err
+			// incomplete request can't be validated, so it's not dispatched
+			return nil, &http.HTTPError{Msg: "Header section is not terminated.", Status: 400}
 		}
 		if err != nil {
 			return nil, err
 		}
+		total += len(*line)
+		if total > limits.MaxHeaderBytes {
+			return nil, &http.HTTPError{Msg: "Header fields are too large.", Status: 431}
+		}
 		if *line == "" {
 			// next is request body...
 			return &headers, nil
 		}
+		if (*line)[0] == ' ' || (*line)[0] == '\t' {
+			// RFC 9112 5.2: a server MUST reject obs-fold in a request
+			return nil, &http.HTTPError{Msg: "Obsolete line folding is not allowed.", Status: 400}
+		}
 		h, err := header.ParseHeader(*line)
 		if err != nil {
-			switch err.(type) {
-			case *http.HTTPError:
+			if _, ok := err.(*http.HTTPError); ok {
 				return nil, err
-			default:
-				continue
 			}
+			// invalid header lines can hide framing headers from us, so they are not skipped
+			return nil, &http.HTTPError{Msg: err.Error(), Status: 400}
 		}
 
-		headers = append(headers, h)
+		headers.Add(h.FieldName, h.FieldValue)
+		if headers.Len() > limits.MaxHeaderCount {
+			return nil, &http.HTTPError{Msg: "Too many header fields.", Status: 431}
+		}
 	}
 
 }
 
-func readBody(reader *bufio.Reader, headers header.Headers) ([]byte, error) {
-	if len(headers.GetTransferEncodings()) == 0 {
+// wireBody returns reader of body framed by Content-Length or chunked.
+func wireBody(reader *bufio.Reader, req *Request, limits Limits) (io.Reader, error) {
+	headers := req.Headers
+	max := limits.MaxBodyBytes
+	tes, err := headers.GetTransferEncodings()
+	if err != nil {
+		return nil, &http.HTTPError{Msg: fmt.Sprintf("Transfer-Encoding is invalid: %v", err), Status: 400}
+	}
+	if len(tes) == 0 {
 		length, err := headers.GetContentLength()
 		if err != nil {
 			return nil, err
 		}
-		var body = make([]byte, length)
-		l, _ := reader.Read(body) // TODO if body is shorter than length?
-		if l != length {
-			return nil, &http.HTTPError{Msg: "Content-Length and real body size are different.", Status: 400}
+		if int64(length) > max {
+			return nil, errBodyTooLarge
 		}
+		return &contentLengthReader{reader: reader, remaining: int64(length)}, nil
+	}
+	if headers.IsChunkedTransferEncoding() {
+		return &chunkedReader{reader: reader, max: max, headers: headers,
+			trailers: &req.Trailers, extensions: &req.ChunkExtensions, limits: limits}, nil
+	}
+	return nil, &http.HTTPError{Msg: "Transfer-Encoding is invalid.", Status: 400}
+}
 
-		cl := headers.GetContentLocation()
-		if cl != nil {
+// decodeBody returns reader decoding transfer codings and content codings of wire.
+func decodeBody(wire io.Reader, headers header.Headers, max int64) (io.Reader, error) {
+	cl := headers.GetContentLocation()
+	if cl != nil {
+		return &lazyReader{open: func() (io.Reader, error) {
 			g, err := ghttp.Get(*cl)
 			if err != nil {
 				return nil, &http.HTTPError{Msg: fmt.Sprintf("Failed to access to Content-Location: %v.", cl), Status: 400}
 			}
-			defer g.Body.Close()
-			b, err := ioutil.ReadAll(g.Body)
-			if err != nil {
-				return nil, &http.HTTPError{Msg: fmt.Sprintf("Failed to access to Content-Location: %v.", cl), Status: 400}
-			}
-			return b, nil
-		}
-
-		return decompress(body, headers), nil
-	} else {
-		if headers.IsChunkedTransferEncoding() {
-			// TODO trailer
-			// TODO compress
-			b := parseChunkBody(reader)
-			t := headers.GetCompressType()
-			log.Println(t)
-			switch t {
-			case header.TRANSFER_ENCODING_GZIP:
-				// TODO untested because I can't find HTTP Client send 'Transfer-Encoding: gzip, chunked
-				gr, _ := gzip.NewReader(bytes.NewReader(b)) // TODO
-				unzip, _ := ioutil.ReadAll(gr)              // TODO
-				return unzip, nil
-			default:
-				return decompress(b, headers), nil
-			}
+			return &limitReader{reader: g.Body, remaining: max, tooLarge: errDecodedBodyTooLarge, closer: g.Body}, nil
+		}}, nil
+	}
 
-		} else {
-			return nil, &http.HTTPError{Msg: "Transfer-Encoding is invalid.", Status: 400}
+	r := wire
+	decoded := false
+	// chunked is the last transfer coding and it's already decoded by wire.
+	// Others are decoded in reverse order of application.
+	tes, err := headers.GetTransferEncodings()
+	if err != nil {
+		return nil, &http.HTTPError{Msg: fmt.Sprintf("Transfer-Encoding is invalid: %v", err), Status: 400}
+	}
+	for i := len(tes) - 2; i >= 0; i-- {
+		if tes[i] != header.TRANSFER_ENCODING_IDENTITY {
+			r = decodingReader(tes[i].ToString(), r)
+			decoded = true
 		}
 	}
-}
-
-func decompress(b []byte, headers header.Headers) []byte {
-	ces := headers.GetContentEncodings()
-	log.Println(ces)
-	for _, ce := range ces {
-		switch ce {
-		case header.CONTENT_CODING_GZIP: // TODO defrate, compress
-			br := bytes.NewReader(b)
-			gr, _ := gzip.NewReader(br)
-			b, _ = ioutil.ReadAll(gr)
-			log.Println(string(b))
-		case header.CONTENT_CODING_IDENTITY:
-			// NOP
-		}
-	}
-	return b
-}
-
-func parseChunkBody(reader *bufio.Reader) []byte {
-	chunks := []byte{}
-	for {
-		l, err := readLine(reader) // TODO error
-		if err == io.EOF {
-			return chunks
 
+	ces, err := headers.GetContentEncodings()
+	if err != nil {
+		return nil, &http.HTTPError{Msg: fmt.Sprintf("Content-Encoding is invalid: %v", err), Status: 400}
+	}
+	for i := len(ces) - 1; i >= 0; i-- {
+		if ces[i] == header.CONTENT_CODING_IDENTITY {
+			continue
 		}
-
-		chunkSize := ParseChunkSize(*l)
-		if chunkSize == 0 {
-			return chunks
+		if _, ok := coding.Lookup(ces[i].ToString()); !ok {
+			return nil, &http.HTTPError{Msg: fmt.Sprintf("Content-Encoding:%v is not supported.", ces[i]), Status: 415}
 		}
-		var chunk = make([]byte, chunkSize)
-		reader.Read(chunk)
-		readLine(reader) // skip to next line
-		chunks = append(chunks, chunk...)
+		r = decodingReader(ces[i].ToString(), r)
+		decoded = true
+	}
+
+	if decoded {
+		r = &limitReader{reader: r, remaining: max, tooLarge: errDecodedBodyTooLarge}
 	}
+	return r, nil
 }
 
-func ParseChunkSize(line string) int64 {
-	v, _ := strconv.ParseInt(line, 16, 64) // TODO error
-	// TODO chunk-ext
-	return v
+// decodingReader decodes r with coding when it's read first.
+func decodingReader(name string, r io.Reader) io.Reader {
+	return &lazyReader{open: func() (io.Reader, error) {
+		dr, err := coding.NewReader(name, r)
+		if err != nil {
+			return nil, &http.HTTPError{Msg: fmt.Sprintf("Invalid %v body.", name), Status: 400}
+		}
+		return &decodeErrorReader{reader: dr, name: name}, nil
+	}}
 }
 
-func readLine(reader *bufio.Reader) (*string, error) {
-	line, err := reader.ReadString('\n')
+var errBodyTooLarge = &http.HTTPError{Msg: "Request body is too large.", Status: 413}
+var errDecodedBodyTooLarge = &http.HTTPError{Msg: "Decoded request body is too large.", Status: 413}
+
+var errLineTooLong = errors.New("line is too long")
+
+// readLineLimit reads line without CRLF.
+// It returns errLineTooLong before buffering much more than max bytes.
+func readLineLimit(reader *bufio.Reader, max int) (*string, error) {
+	line, err := readRawLineLimit(reader, max)
 	if err != nil {
 		return nil, err
 	}
-	t := strings.Trim(line, "\r\n")
+	t := strings.Trim(string(line), "\r\n")
 	return &t, nil
 }
+
+// readRawLineLimit reads line with its line terminator.
+// It returns errLineTooLong before buffering much more than max bytes.
+func readRawLineLimit(reader *bufio.Reader, max int) ([]byte, error) {
+	line := []byte{}
+	for {
+		b, err := reader.ReadSlice('\n')
+		line = append(line, b...)
+		if len(bytes.TrimRight(line, "\r\n")) > max {
+			return nil, errLineTooLong
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return line, nil
+	}
+}
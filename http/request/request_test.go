@@ -2,10 +2,17 @@ package request
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/coding"
 )
 
 func TestParseRequest_Get(t *testing.T) {
@@ -14,6 +21,7 @@ func TestParseRequest_Get(t *testing.T) {
 Header1: aaa
 Header2: bbb ccc
 Host: example.com
+
 `
 
 	result, err := ParseRequest(bufio.NewReader((strings.NewReader(request))))
@@ -29,20 +37,24 @@ Host: example.com
 	if result.StartLine.RequestTarget != "/" {
 		t.Errorf("Unexpected request target: %v", result.StartLine.RequestTarget)
 	}
-	if result.Headers[0].FieldName != "HEADER1" {
-		t.Errorf("Unexpected header name: %v", result.Headers[0].FieldName)
+	if result.Headers.Fields()[0].FieldName != "Header1" {
+		t.Errorf("Unexpected header name: %v", result.Headers.Fields()[0].FieldName)
+	}
+	if result.Headers.Fields()[0].FieldValue != "aaa" {
+		t.Errorf("Unexpected header name: %v", result.Headers.Fields()[0].FieldValue)
 	}
-	if result.Headers[0].FieldValue != "aaa" {
-		t.Errorf("Unexpected header name: %v", result.Headers[0].FieldValue)
+	if result.Headers.Fields()[1].FieldName != "Header2" {
+		t.Errorf("Unexpected header name: %v", result.Headers.Fields()[1].FieldName)
 	}
-	if result.Headers[1].FieldName != "HEADER2" {
-		t.Errorf("Unexpected header name: %v", result.Headers[1].FieldName)
+	if result.Headers.Fields()[1].FieldValue != "bbb ccc" {
+		t.Errorf("Unexpected header name: %v", result.Headers.Fields()[1].FieldValue)
 	}
-	if result.Headers[1].FieldValue != "bbb ccc" {
-		t.Errorf("Unexpected header name: %v", result.Headers[1].FieldValue)
+	body, err := ioutil.ReadAll(result.Body)
+	if err != nil {
+		t.Fatal(err)
 	}
-	if len(result.Body) != 0 {
-		t.Errorf("Unexpected body: %v", result.Body)
+	if len(body) != 0 {
+		t.Errorf("Unexpected body: %v", body)
 	}
 }
 
@@ -71,20 +83,24 @@ bbbbb
 	if result.StartLine.RequestTarget != "/" {
 		t.Errorf("Unexpected request target: %v", result.StartLine.RequestTarget)
 	}
-	if result.Headers[0].FieldName != "HEADER1" {
-		t.Errorf("Unexpected header name: %v", result.Headers[0].FieldName)
+	if result.Headers.Fields()[0].FieldName != "Header1" {
+		t.Errorf("Unexpected header name: %v", result.Headers.Fields()[0].FieldName)
 	}
-	if result.Headers[0].FieldValue != "aaa" {
-		t.Errorf("Unexpected header name: %v", result.Headers[0].FieldValue)
+	if result.Headers.Fields()[0].FieldValue != "aaa" {
+		t.Errorf("Unexpected header name: %v", result.Headers.Fields()[0].FieldValue)
 	}
-	if result.Headers[1].FieldName != "HEADER2" {
-		t.Errorf("Unexpected header name: %v", result.Headers[1].FieldName)
+	if result.Headers.Fields()[1].FieldName != "Header2" {
+		t.Errorf("Unexpected header name: %v", result.Headers.Fields()[1].FieldName)
 	}
-	if result.Headers[1].FieldValue != "bbb ccc" {
-		t.Errorf("Unexpected header name: %v", result.Headers[1].FieldValue)
+	if result.Headers.Fields()[1].FieldValue != "bbb ccc" {
+		t.Errorf("Unexpected header name: %v", result.Headers.Fields()[1].FieldValue)
 	}
-	if string(result.Body) != "aaaaa\nbbbbb\n" {
-		t.Errorf("Unexpected body: %v", string(result.Body))
+	body, err := ioutil.ReadAll(result.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "aaaaa\nbbbbb\n" {
+		t.Errorf("Unexpected body: %v", string(body))
 	}
 }
 
@@ -100,23 +116,445 @@ aaaaa
 bbbbb
 `
 
-	_, err := ParseRequest(bufio.NewReader((strings.NewReader(request))))
+	result, err := ParseRequest(bufio.NewReader((strings.NewReader(request))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(result.Body)
 	if err.Error() != "Content-Length and real body size are different." {
 		t.Errorf("Unexpected err: %v", err.Error())
 	}
 }
 
-func TestParseChunkBody(t *testing.T) {
-	request := `1
-a
-2
-bb
-1e
-aaaaaaaaaabbbbbbbbbbcccccccccc
-0`
-	body := parseChunkBody(bufio.NewReader((strings.NewReader(request))))
+func TestChunkedReader(t *testing.T) {
+	request := "1\r\na\r\n2\r\nbb\r\n1e\r\naaaaaaaaaabbbbbbbbbbcccccccccc\r\n0\r\n\r\n"
+	body, err := ioutil.ReadAll(&chunkedReader{reader: bufio.NewReader((strings.NewReader(request))), max: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if string(body) != `abbaaaaaaaaaabbbbbbbbbbcccccccccc` {
 		t.Errorf("Unexpected body: %v", string(body))
 	}
 }
+
+func TestParseRequestHead_Limits(t *testing.T) {
+	limits := Limits{MaxRequestLineBytes: 20, MaxHeaderFieldBytes: 20, MaxHeaderCount: 3, MaxHeaderBytes: 40}
+	tests := []struct {
+		name    string
+		request string
+		status  int
+	}{
+		{
+			name:    "request line",
+			request: "GET /aaaaaaaaaaaaaaaaaaaa HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			status:  414,
+		},
+		{
+			name:    "header field",
+			request: "GET / HTTP/1.1\r\nHost: example.com\r\nHeader: aaaaaaaaaaaaaaaaaaaa\r\n\r\n",
+			status:  431,
+		},
+		{
+			name:    "header count",
+			request: "GET / HTTP/1.1\r\nHost: example.com\r\nA: a\r\nB: b\r\nC: c\r\n\r\n",
+			status:  431,
+		},
+		{
+			name:    "header bytes",
+			request: "GET / HTTP/1.1\r\nHost: example.com\r\nHeader1: aaaaa\r\nHeader2: aaaaa\r\n\r\n",
+			status:  431,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRequestHead(bufio.NewReader(strings.NewReader(tt.request)), limits)
+			httpErr, ok := err.(*http.HTTPError)
+			if !ok {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if httpErr.Status != tt.status {
+				t.Errorf("Unexpected status: %v", httpErr.Status)
+			}
+		})
+	}
+
+	_, err := ParseRequestHead(bufio.NewReader(strings.NewReader("GET / HTTP/1.1\r\nHost: example.com\r\nA: a\r\n\r\n")), limits)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestReadBody_Limits(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	writer.Write(make([]byte, 1024*1024))
+	writer.Close()
+	bomb := buffer.Bytes()
+
+	limits := Limits{MaxBodyBytes: 10 * 1024, MaxDecodedBodyBytes: 100 * 1024}
+	tests := []struct {
+		name    string
+		request string
+	}{
+		{
+			name:    "Content-Length",
+			request: "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 1000000000\r\n\r\naaaaa",
+		},
+		{
+			name:    "chunked",
+			request: "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\nfffffff\r\naaaaa",
+		},
+		{
+			name: "Content-Encoding",
+			request: fmt.Sprintf("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Encoding: gzip\r\nContent-Length: %v\r\n\r\n%v",
+				len(bomb), string(bomb)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.request))
+			req, err := ParseRequestHead(reader, limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ReadBody(reader, req, limits)
+			if err == nil {
+				_, err = ioutil.ReadAll(req.Body)
+			}
+			httpErr, ok := err.(*http.HTTPError)
+			if !ok {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if httpErr.Status != 413 {
+				t.Errorf("Unexpected status: %v", httpErr.Status)
+			}
+		})
+	}
+}
+
+func TestReadBody_Lazy(t *testing.T) {
+	rd, wr := io.Pipe()
+	go wr.Write([]byte("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 10\r\n\r\n"))
+	reader := bufio.NewReader(rd)
+
+	req, err := ParseRequestHead(reader, Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// body is not sent yet
+	if err := ReadBody(reader, req, Limits{}); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		wr.Write([]byte("aaaaa"))
+		wr.Write([]byte("bbbbb"))
+	}()
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "aaaaabbbbb" {
+		t.Errorf("Unexpected body: %v", string(b))
+	}
+}
+
+func TestReadBody_Trailers(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  string
+		trailers []string
+		status   int
+	}{
+		{
+			name:     "declared",
+			request:  "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\nTrailer: X-Digest, X-Status\r\n\r\n5\r\naaaaa\r\n0\r\nX-Digest: abc\r\nX-Status: ok\r\n\r\n",
+			trailers: []string{"X-Digest: abc", "X-Status: ok"},
+		},
+		{
+			name:    "undeclared",
+			request: "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\nTrailer: X-Digest\r\n\r\n5\r\naaaaa\r\n0\r\nX-Status: ok\r\n\r\n",
+			status:  400,
+		},
+		{
+			name:    "forbidden",
+			request: "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\nTrailer: Content-Length\r\n\r\n5\r\naaaaa\r\n0\r\nContent-Length: 5\r\n\r\n",
+			status:  400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.request + "GET / HTTP/1.1\r\n"))
+			req, err := ParseRequest(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadAll(req.Body)
+			if tt.status != 0 {
+				httpErr, ok := err.(*http.HTTPError)
+				if !ok {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if httpErr.Status != tt.status {
+					t.Errorf("Unexpected status: %v", httpErr.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != "aaaaa" {
+				t.Errorf("Unexpected body: %v", string(b))
+			}
+			if req.Trailers.Len() != len(tt.trailers) {
+				t.Fatalf("Unexpected trailers: %v", req.Trailers.ToString())
+			}
+			for i, tr := range tt.trailers {
+				if req.Trailers.Fields()[i].ToString() != tr {
+					t.Errorf("Unexpected trailer: %v", req.Trailers.Fields()[i].ToString())
+				}
+			}
+			// next request is not consumed
+			l, _ := reader.ReadString('\n')
+			if l != "GET / HTTP/1.1\r\n" {
+				t.Errorf("Unexpected next line: %v", l)
+			}
+		})
+	}
+}
+
+func TestChunkedReader_Extensions(t *testing.T) {
+	request := "5;name=value\r\naaaaa\r\n5 ; a ; b = \"q\\\"s\"\r\nbbbbb\r\n0;last\r\n\r\n"
+	exts := []ChunkExtension{}
+	// one byte per read like chunks split across TCP packets
+	reader := bufio.NewReaderSize(iotest.OneByteReader(strings.NewReader(request)), 16)
+	body, err := ioutil.ReadAll(&chunkedReader{reader: reader, max: 100, extensions: &exts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "aaaaabbbbb" {
+		t.Errorf("Unexpected body: %v", string(body))
+	}
+
+	expected := []ChunkExtension{
+		{Chunk: 0, Name: "name", Value: "value"},
+		{Chunk: 1, Name: "a"},
+		{Chunk: 1, Name: "b", Value: `q"s`},
+		{Chunk: 2, Name: "last"},
+	}
+	if len(exts) != len(expected) {
+		t.Fatalf("Unexpected extensions: %v", exts)
+	}
+	for i, e := range expected {
+		if exts[i] != e {
+			t.Errorf("Unexpected extension: %v", exts[i])
+		}
+	}
+}
+
+func TestChunkedReader_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not hex", body: "zz\r\naaaaa\r\n0\r\n\r\n"},
+		{name: "empty size", body: "\r\naaaaa\r\n0\r\n\r\n"},
+		{name: "negative", body: "-5\r\naaaaa\r\n0\r\n\r\n"},
+		{name: "overflow", body: "10000000000000000\r\naaaaa\r\n0\r\n\r\n"},
+		{name: "invalid extension", body: "5;\r\naaaaa\r\n0\r\n\r\n"},
+		{name: "unterminated quoted extension", body: "5;a=\"b\r\naaaaa\r\n0\r\n\r\n"},
+		{name: "missing CRLF", body: "5\r\naaaaaa\r\n0\r\n\r\n"},
+		{name: "short chunk", body: "5\r\naaa"},
+		{name: "missing last chunk", body: "5\r\naaaaa\r\n"},
+		{name: "missing end of trailer", body: "5\r\naaaaa\r\n0\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &chunkedReader{reader: bufio.NewReader(strings.NewReader(tt.body)), max: 1 << 62}
+			_, err := ioutil.ReadAll(r)
+			httpErr, ok := err.(*http.HTTPError)
+			if !ok {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if httpErr.Status != 400 {
+				t.Errorf("Unexpected status: %v", httpErr.Status)
+			}
+		})
+	}
+}
+
+func TestChunkedReader_ExtensionLimits(t *testing.T) {
+	ext := ";e=" + strings.Repeat("v", 100)
+	tests := []struct {
+		name   string
+		body   string
+		limits Limits
+		max    int64
+		status int
+	}{
+		{name: "too many extensions", body: strings.Repeat("1;a;b\r\na\r\n", 3) + "0\r\n\r\n",
+			limits: Limits{MaxChunkExtensions: 5}, max: 1 << 62, status: 400},
+		{name: "too large extensions", body: strings.Repeat("1"+ext+"\r\na\r\n", 3) + "0\r\n\r\n",
+			limits: Limits{MaxChunkExtensionBytes: 250}, max: 1 << 62, status: 413},
+		{name: "size lines count toward max", body: strings.Repeat("1"+ext+"\r\na\r\n", 3) + "0\r\n\r\n",
+			max: 200, status: 413},
+		{name: "too long size line", body: "1" + strings.Repeat(ext, 10) + "\r\na\r\n0\r\n\r\n",
+			limits: Limits{MaxHeaderFieldBytes: 512}, max: 1 << 62, status: 400},
+		{name: "max chunk size", body: "1\r\na\r\n7fffffffffffffff\r\n" + strings.Repeat("a", 5000) + "\r\n0\r\n\r\n",
+			max: 100, status: 413},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exts := []ChunkExtension{}
+			r := &chunkedReader{reader: bufio.NewReader(strings.NewReader(tt.body)), max: tt.max, limits: tt.limits, extensions: &exts}
+			_, err := ioutil.ReadAll(r)
+			httpErr, ok := err.(*http.HTTPError)
+			if !ok {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if httpErr.Status != tt.status {
+				t.Errorf("Unexpected status: %v", httpErr.Status)
+			}
+		})
+	}
+}
+
+func TestReadBody_TransferCodings(t *testing.T) {
+	tests := []struct {
+		te      string
+		codings []string // in order of application
+	}{
+		{te: "gzip, chunked", codings: []string{"gzip"}},
+		{te: "deflate, chunked", codings: []string{"deflate"}},
+		{te: "compress, chunked", codings: []string{"compress"}},
+		{te: "identity, chunked", codings: []string{}},
+		{te: "Deflate, GZIP, chunked", codings: []string{"deflate", "gzip"}},
+		{te: "gzip\r\nTransfer-Encoding: compress, chunked", codings: []string{"gzip", "compress"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.te, func(t *testing.T) {
+			body := []byte("hello transfer coding")
+			for _, c := range tt.codings {
+				var b bytes.Buffer
+				w, err := coding.NewWriter(c, &b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(body)
+				w.Close()
+				body = b.Bytes()
+			}
+			request := fmt.Sprintf("POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: %v\r\n\r\n%x\r\n%v\r\n0\r\n\r\n", tt.te, len(body), string(body))
+			result, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			decoded, err := ioutil.ReadAll(result.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(decoded) != "hello transfer coding" {
+				t.Errorf("Unexpected body: %v", string(decoded))
+			}
+		})
+	}
+}
+
+func TestReadBody_InvalidTransferCoding(t *testing.T) {
+	for _, body := range []string{"not gzip", "\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xffbroken"} {
+		request := fmt.Sprintf("POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: gzip, chunked\r\n\r\n%x\r\n%v\r\n0\r\n\r\n", len(body), body)
+		result, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		_, err = ioutil.ReadAll(result.Body)
+		httpErr, ok := err.(*http.HTTPError)
+		if !ok {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if httpErr.Status != 400 {
+			t.Errorf("Unexpected status: %v", httpErr.Status)
+		}
+	}
+}
+
+func TestReadBody_ContentCodings(t *testing.T) {
+	for _, ce := range []string{"gzip", "deflate", "compress", "x-gzip", "deflate, compress"} {
+		body := []byte("hello content coding")
+		for _, c := range strings.Split(ce, ",") {
+			var b bytes.Buffer
+			w, err := coding.NewWriter(strings.TrimSpace(c), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(body)
+			w.Close()
+			body = b.Bytes()
+		}
+		request := fmt.Sprintf("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Encoding: %v\r\nContent-Length: %v\r\n\r\n%v", ce, len(body), string(body))
+		result, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		decoded, err := ioutil.ReadAll(result.Body)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(decoded) != "hello content coding" {
+			t.Errorf("Unexpected body: %v", string(decoded))
+		}
+	}
+}
+
+func TestReadBody_UnsupportedContentCoding(t *testing.T) {
+	request := "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Encoding: br\r\nContent-Length: 5\r\n\r\nhello"
+	_, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	httpErr, ok := err.(*http.HTTPError)
+	if !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if httpErr.Status != 415 {
+		t.Errorf("Unexpected status: %v", httpErr.Status)
+	}
+}
+
+func TestParseRequest_ContentType(t *testing.T) {
+	request := "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: text/plain; charset=\"utf-8\"\r\nContent-Length: 0\r\n\r\n"
+	result, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ct := result.ContentType()
+	if charset, _ := ct.Parameter("charset"); ct.MediaType() != "text/plain" || charset != "utf-8" {
+		t.Errorf("Unexpected content type: %v", ct)
+	}
+
+	request = "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: text\r\nContent-Length: 0\r\n\r\n"
+	_, err = ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if httpErr, ok := err.(*http.HTTPError); !ok || httpErr.Status != 400 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRequest_Cookies(t *testing.T) {
+	request := "GET / HTTP/1.1\r\nHost: example.com\r\nCookie: a=1; b=2\r\nCookie: a=3\r\n\r\n"
+	req, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookies := req.Cookies(); len(cookies) != 3 {
+		t.Errorf("Unexpected cookies: %v", cookies)
+	}
+	if c, ok := req.Cookie("a"); !ok || c.Value != "1" {
+		t.Errorf("Unexpected cookie: %v", c)
+	}
+	if _, ok := req.Cookie("c"); ok {
+		t.Errorf("Unexpected cookie: c")
+	}
+}
@@ -0,0 +1,149 @@
+package request
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/inabajunmr/http11server/http"
+)
+
+// Payloads which front proxies and backends may frame differently.
+func TestParseRequest_Smuggling(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		status  int
+	}{
+		{"CL.TE", "Content-Length: 13\r\nTransfer-Encoding: chunked\r\n", 400},
+		{"TE.CL", "Transfer-Encoding: chunked\r\nContent-Length: 4\r\n", 400},
+		{"TE.TE unknown coding", "Transfer-Encoding: chunked\r\nTransfer-Encoding: xchunked\r\n", 400},
+		{"unknown coding", "Transfer-Encoding: xchunked\r\n", 501},
+		{"chunked is not final", "Transfer-Encoding: chunked, gzip\r\n", 400},
+		{"chunked twice", "Transfer-Encoding: chunked, chunked\r\n", 400},
+		{"only gzip", "Transfer-Encoding: gzip\r\n", 400},
+		{"empty Transfer-Encoding", "Transfer-Encoding: \r\n", 400},
+		{"malformed Transfer-Encoding", "Transfer-Encoding: gzip chunked\r\n", 400},
+		{"space before colon", "Transfer-Encoding : chunked\r\n", 400},
+		{"control char in name", "Transfer-Encoding\x0b: chunked\r\n", 400},
+		{"obs-fold", "X-Foo: bar\r\n Transfer-Encoding: chunked\r\n", 400},
+		{"obs-fold with tab", "Transfer-Encoding:\r\n\tchunked\r\n", 400},
+		{"no colon", "Transfer-Encoding chunked\r\n", 400},
+		{"differing Content-Length", "Content-Length: 5\r\nContent-Length: 6\r\n", 400},
+		{"differing Content-Length list", "Content-Length: 5, 6\r\n", 400},
+		{"plus sign", "Content-Length: +5\r\n", 400},
+		{"minus sign", "Content-Length: -1\r\n", 400},
+		{"hex", "Content-Length: 0x5\r\n", 400},
+		{"space in value", "Content-Length: 1 2\r\n", 400},
+		{"empty Content-Length", "Content-Length: \r\n", 400},
+		{"overflow", "Content-Length: 99999999999999999999999\r\n", 413},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := "POST / HTTP/1.1\r\nHost: example.com\r\n" + tt.headers + "\r\n" +
+				"0\r\n\r\nGET /smuggled HTTP/1.1\r\nHost: example.com\r\n\r\n"
+			_, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+			if err == nil {
+				t.Fatalf("Unexpected success")
+			}
+			herr, ok := err.(*http.HTTPError)
+			if !ok {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if herr.Status != tt.status {
+				t.Errorf("Unexpected status: %v", herr.Status)
+			}
+		})
+	}
+}
+
+// Chunked bodies whose framing is ambiguous without CRLF.
+func TestParseRequest_SmugglingChunkFraming(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"bare LF after chunk data", "5\r\nhello\n0\r\n\r\n"},
+		{"bare CR after chunk data", "5\r\nhello\r0\r\n\r\n"},
+		{"no CRLF after chunk data", "5\r\nhello0\r\n\r\n"},
+		{"bare LF after chunk size", "5\nhello\r\n0\r\n\r\n"},
+		{"CR CR LF after chunk size", "5\r\r\nhello\r\n0\r\n\r\n"},
+		{"bare LF after last chunk size", "5\r\nhello\r\n0\n\r\n"},
+		{"LF only", "5\nhello\n0\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" + tt.body +
+				"GET /smuggled HTTP/1.1\r\nHost: example.com\r\n\r\n"
+			result, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			_, err = ioutil.ReadAll(result.Body)
+			herr, ok := err.(*http.HTTPError)
+			if !ok {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if herr.Status != 400 {
+				t.Errorf("Unexpected status: %v", herr.Status)
+			}
+		})
+	}
+}
+
+// Requests whose header section is cut by EOF can't be validated.
+func TestParseRequest_IncompleteHeaderSection(t *testing.T) {
+	tests := []string{
+		"GET / HTTP/1.1\r\n",
+		"GET / HTTP/1.1\r\nX-Foo: bar\r\n",
+		"POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\nTransfer-Encoding: chunked\r\n",
+		"GET / HTTP/1.1\r\nHost: example.com\r\nX-Foo: ba",
+	}
+	for _, request := range tests {
+		_, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+		herr, ok := err.(*http.HTTPError)
+		if !ok {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if herr.Status != 400 {
+			t.Errorf("Unexpected status: %v", herr.Status)
+		}
+	}
+}
+
+func TestParseRequest_ContentLengthNormalized(t *testing.T) {
+	tests := []string{
+		"Content-Length: 5\r\nContent-Length: 5\r\n",
+		"Content-Length: 5, 5\r\n",
+		"Content-Length: 005\r\n",
+	}
+	for _, headers := range tests {
+		request := "POST / HTTP/1.1\r\nHost: example.com\r\n" + headers + "\r\nhelloGET"
+		result, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if v := result.Headers.Get("Content-Length"); v != "5" {
+			t.Errorf("Unexpected Content-Length: %v", v)
+		}
+		body, err := ioutil.ReadAll(result.Body)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(body) != "hello" {
+			t.Errorf("Unexpected body: %v", string(body))
+		}
+	}
+}
+
+func TestParseRequest_EmptyFieldValue(t *testing.T) {
+	request := "GET / HTTP/1.1\r\nHost: example.com\r\nX-Empty:\r\n\r\n"
+	result, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Headers.Len() != 2 {
+		t.Errorf("Unexpected headers: %v", result.Headers)
+	}
+}
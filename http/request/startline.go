@@ -21,13 +21,16 @@ const (
 )
 
 type StartLine struct {
-	Method        HTTPMethod
+	Method HTTPMethod
+	// RequestTarget is request-target as received.
 	RequestTarget string
-	Version       http.HTTPVersion
+	// Target is parsed RequestTarget.
+	Target  Target
+	Version http.HTTPVersion
 }
 
 func (s StartLine) ToString() string {
-	return fmt.Sprintf("%v %v %v", s.Method.ToString(), s.RequestTarget, s.Version.ToString()) // TODO
+	return fmt.Sprintf("%v %v %v", s.Method.ToString(), s.RequestTarget, s.Version.ToString())
 
 }
 
@@ -42,13 +45,16 @@ func ParseStartLine(line string) (*StartLine, error) {
 	if err != nil {
 		return nil, &http.HTTPError{Msg: fmt.Sprintf("HTTP method %v is not implemented", s[0]), Status: 400}
 	}
-	requestTarget := s[1] // TODO parse
 	httpVersion := s[2]
 	if httpVersion != "HTTP/1.1" {
 		return nil, &http.HTTPError{Msg: fmt.Sprintf("%v is not supported HTTP version", s[2]), Status: 400}
 	}
+	target, err := ParseTarget(method, s[1])
+	if err != nil {
+		return nil, err
+	}
 
-	return &StartLine{method, requestTarget, http.HTTP11}, nil
+	return &StartLine{Method: method, RequestTarget: s[1], Target: *target, Version: http.HTTP11}, nil
 
 }
 
@@ -0,0 +1,217 @@
+package request
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/inabajunmr/http11server/http"
+)
+
+type TargetForm int
+
+const (
+	TARGET_FORM_ORIGIN TargetForm = iota
+	TARGET_FORM_ABSOLUTE
+	TARGET_FORM_AUTHORITY
+	TARGET_FORM_ASTERISK
+)
+
+func (f TargetForm) ToString() string {
+	switch f {
+	case TARGET_FORM_ORIGIN:
+		return "origin-form"
+	case TARGET_FORM_ABSOLUTE:
+		return "absolute-form"
+	case TARGET_FORM_AUTHORITY:
+		return "authority-form"
+	case TARGET_FORM_ASTERISK:
+		return "asterisk-form"
+	}
+	return ""
+}
+
+// Target is request-target parsed by its form (RFC 9112 3.2).
+//
+//	request-target = origin-form / absolute-form / authority-form / asterisk-form
+type Target struct {
+	Form TargetForm
+	// Scheme is lowercased "http" or "https" of absolute-form.
+	Scheme string
+	// Host is uri-host of absolute-form and authority-form. IP-literal keeps its brackets.
+	Host string
+	// Port is port of absolute-form and authority-form. It's empty if it's not specified.
+	Port string
+	// Path is percent-encoded absolute-path. It's "/" for absolute-form without path and
+	// empty for authority-form and asterisk-form.
+	Path string
+	// RawQuery is percent-encoded query without "?".
+	RawQuery string
+	// Segments are percent-decoded segments of Path. "/a%2Fb/c" has "a/b" and "c".
+	Segments []string
+}
+
+// Authority returns host and port of the target like "example.com:8080".
+func (t Target) Authority() string {
+	if t.Port == "" {
+		return t.Host
+	}
+	return t.Host + ":" + t.Port
+}
+
+// ParseTarget parses request-target and checks its form fits method.
+// CONNECT requires authority-form and only OPTIONS can use asterisk-form.
+func ParseTarget(method HTTPMethod, target string) (*Target, error) {
+	switch {
+	case method == CONNECT:
+		return parseAuthorityForm(target)
+	case target == "*":
+		if method != OPTIONS {
+			return nil, targetError(target)
+		}
+		return &Target{Form: TARGET_FORM_ASTERISK}, nil
+	case strings.HasPrefix(target, "/"):
+		return parseOriginForm(target)
+	}
+	return parseAbsoluteForm(target)
+}
+
+// origin-form = absolute-path [ "?" query ]
+func parseOriginForm(target string) (*Target, error) {
+	t := &Target{Form: TARGET_FORM_ORIGIN}
+	if err := t.setPathAndQuery(target); err != nil {
+		return nil, targetError(target)
+	}
+	return t, nil
+}
+
+// absolute-form = absolute-URI. Only http and https URIs having authority are accepted.
+func parseAbsoluteForm(target string) (*Target, error) {
+	i := strings.Index(target, "://")
+	if i <= 0 {
+		return nil, targetError(target)
+	}
+	scheme := strings.ToLower(target[:i])
+	if scheme != "http" && scheme != "https" {
+		return nil, targetError(target)
+	}
+	rest := target[i+3:]
+	end := strings.IndexAny(rest, "/?")
+	if end == -1 {
+		end = len(rest)
+	}
+	host, port, err := parseAuthority(rest[:end])
+	if err != nil || host == "" {
+		// http URI with empty host is invalid (RFC 9110 4.2.1)
+		return nil, targetError(target)
+	}
+	t := &Target{Form: TARGET_FORM_ABSOLUTE, Scheme: scheme, Host: host, Port: port}
+	pathAndQuery := rest[end:]
+	if !strings.HasPrefix(pathAndQuery, "/") {
+		pathAndQuery = "/" + pathAndQuery
+	}
+	if err := t.setPathAndQuery(pathAndQuery); err != nil {
+		return nil, targetError(target)
+	}
+	return t, nil
+}
+
+// authority-form = uri-host ":" port
+func parseAuthorityForm(target string) (*Target, error) {
+	host, port, err := parseAuthority(target)
+	if err != nil || host == "" || port == "" {
+		return nil, targetError(target)
+	}
+	return &Target{Form: TARGET_FORM_AUTHORITY, Host: host, Port: port}, nil
+}
+
+func (t *Target) setPathAndQuery(s string) error {
+	path, query := s, ""
+	hasQuery := false
+	if i := strings.IndexByte(s, '?'); i != -1 {
+		path, query, hasQuery = s[:i], s[i+1:], true
+	}
+	if !validChars(path, "/") || (hasQuery && !validChars(query, "/?")) {
+		return fmt.Errorf("invalid path or query")
+	}
+	segments := []string{}
+	for _, segment := range strings.Split(path[1:], "/") {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, decoded)
+	}
+	t.Path, t.RawQuery, t.Segments = path, query, segments
+	return nil
+}
+
+// parseAuthority parses uri-host [ ":" port ] and returns host and port.
+// userinfo is not allowed for http and https (RFC 9110 4.2.4).
+func parseAuthority(authority string) (string, string, error) {
+	host, port := authority, ""
+	if strings.HasPrefix(authority, "[") {
+		// IP-literal = "[" IPv6address "]"
+		end := strings.IndexByte(authority, ']')
+		if end == -1 || !strings.Contains(authority[1:end], ":") || net.ParseIP(authority[1:end]) == nil {
+			return "", "", fmt.Errorf("invalid IP-literal")
+		}
+		host = authority[:end+1]
+		rest := authority[end+1:]
+		if rest != "" {
+			if rest[0] != ':' {
+				return "", "", fmt.Errorf("invalid authority")
+			}
+			port = rest[1:]
+		}
+	} else if i := strings.LastIndexByte(authority, ':'); i != -1 {
+		host, port = authority[:i], authority[i+1:]
+	}
+	if !isRegName(host) && !strings.HasPrefix(host, "[") {
+		return "", "", fmt.Errorf("invalid host")
+	}
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n > 65535 || !isDigits(port) {
+			return "", "", fmt.Errorf("invalid port")
+		}
+	}
+	return host, port, nil
+}
+
+// reg-name = *( unreserved / pct-encoded / sub-delims ). IPv4address is also a reg-name.
+func isRegName(host string) bool {
+	return validChars(host, "")
+}
+
+// validChars returns true if s consists of pchar and extra characters.
+// pchar = unreserved / pct-encoded / sub-delims / ":" / "@", but ":" and "@" are
+// allowed only if extra is not empty since they are not allowed in reg-name.
+func validChars(s string, extra string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%':
+			if i+2 >= len(s) || !isHexDigit(s[i+1]) || !isHexDigit(s[i+2]) {
+				return false
+			}
+			i += 2
+		case isUnreserved(c) || strings.IndexByte("!$&'()*+,;=", c) >= 0:
+		case extra != "" && (c == ':' || c == '@'):
+		case strings.IndexByte(extra, c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// unreserved = ALPHA / DIGIT / "-" / "." / "_" / "~"
+func isUnreserved(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte("-._~", c) >= 0
+}
+
+func targetError(target string) error {
+	return &http.HTTPError{Msg: fmt.Sprintf("Request target %v is invalid.", target), Status: 400}
+}
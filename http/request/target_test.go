@@ -0,0 +1,99 @@
+package request
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inabajunmr/http11server/http"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		method   HTTPMethod
+		target   string
+		expected Target
+	}{
+		{GET, "/", Target{Form: TARGET_FORM_ORIGIN, Path: "/", Segments: []string{""}}},
+		{GET, "/a/b%20c?x=1&y=/?", Target{Form: TARGET_FORM_ORIGIN, Path: "/a/b%20c", RawQuery: "x=1&y=/?", Segments: []string{"a", "b c"}}},
+		{GET, "/a%2Fb/c", Target{Form: TARGET_FORM_ORIGIN, Path: "/a%2Fb/c", Segments: []string{"a/b", "c"}}},
+		{GET, "/users/:id@x", Target{Form: TARGET_FORM_ORIGIN, Path: "/users/:id@x", Segments: []string{"users", ":id@x"}}},
+		{GET, "http://Example.com:8080/p?q", Target{Form: TARGET_FORM_ABSOLUTE, Scheme: "http", Host: "Example.com", Port: "8080", Path: "/p", RawQuery: "q", Segments: []string{"p"}}},
+		{POST, "HTTPS://example.com", Target{Form: TARGET_FORM_ABSOLUTE, Scheme: "https", Host: "example.com", Path: "/", Segments: []string{""}}},
+		{GET, "http://[::1]:80?a", Target{Form: TARGET_FORM_ABSOLUTE, Scheme: "http", Host: "[::1]", Port: "80", Path: "/", RawQuery: "a", Segments: []string{""}}},
+		{CONNECT, "example.com:443", Target{Form: TARGET_FORM_AUTHORITY, Host: "example.com", Port: "443"}},
+		{CONNECT, "192.0.2.1:443", Target{Form: TARGET_FORM_AUTHORITY, Host: "192.0.2.1", Port: "443"}},
+		{OPTIONS, "*", Target{Form: TARGET_FORM_ASTERISK}},
+	}
+	for _, tt := range tests {
+		actual, err := ParseTarget(tt.method, tt.target)
+		if err != nil {
+			t.Errorf("Unexpected error: %v for %v.", err, tt.target)
+			continue
+		}
+		if !reflect.DeepEqual(*actual, tt.expected) {
+			t.Errorf("Unexpected target: %+v for %v.", *actual, tt.target)
+		}
+	}
+}
+
+func TestParseTarget_Invalid(t *testing.T) {
+	tests := []struct {
+		method HTTPMethod
+		target string
+	}{
+		{GET, "*"},
+		{CONNECT, "/path"},
+		{CONNECT, "example.com"},
+		{CONNECT, "http://example.com:443"},
+		{GET, "example.com:443"},
+		{GET, "ftp://example.com/"},
+		{GET, "http:///path"},
+		{GET, "http://user@example.com/"},
+		{GET, "http://[192.0.2.1]/"},
+		{GET, "http://[::1/"},
+		{GET, "http://example.com:99999/"},
+		{GET, "http://example.com:8o/"},
+		{GET, "/a%zz"},
+		{GET, "/a%2"},
+		{GET, "/a#fragment"},
+		{GET, "/a\"b"},
+		{GET, "/a?b\\c"},
+		{GET, ""},
+	}
+	for _, tt := range tests {
+		actual, err := ParseTarget(tt.method, tt.target)
+		if err == nil {
+			t.Errorf("Unexpected target: %+v for %v %v.", *actual, tt.method.ToString(), tt.target)
+			continue
+		}
+		if httpErr, ok := err.(*http.HTTPError); !ok || httpErr.Status != 400 {
+			t.Errorf("Unexpected error: %v.", err)
+		}
+	}
+}
+
+func TestParseRequest_AbsoluteFormHost(t *testing.T) {
+	request := "GET http://example.com:8080/a HTTP/1.1\r\nHost: other.example\r\n\r\n"
+	result, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Headers.Get("Host") != "example.com:8080" {
+		t.Errorf("Unexpected Host: %v.", result.Headers.Get("Host"))
+	}
+	if result.StartLine.RequestTarget != "http://example.com:8080/a" || result.StartLine.Target.Path != "/a" {
+		t.Errorf("Unexpected start line: %+v.", result.StartLine)
+	}
+}
+
+func TestParseRequest_InvalidHost(t *testing.T) {
+	for _, host := range []string{"user@example.com", "example.com:port", "exa mple.com"} {
+		request := "GET / HTTP/1.1\r\nHost: " + host + "\r\n\r\n"
+		_, err := ParseRequest(bufio.NewReader(strings.NewReader(request)))
+		if httpErr, ok := err.(*http.HTTPError); !ok || httpErr.Status != 400 {
+			t.Errorf("Unexpected error: %v for %v.", err, host)
+		}
+	}
+}
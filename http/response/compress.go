@@ -0,0 +1,233 @@
+package response
+
+import (
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/inabajunmr/http11server/http/coding"
+	"github.com/inabajunmr/http11server/http/header"
+	"github.com/inabajunmr/http11server/http/request"
+)
+
+// DefaultCompressMinSize is the smallest body compressed by Compressor.
+const DefaultCompressMinSize = 1024
+
+// DefaultSkipTypes are media types already compressed.
+var DefaultSkipTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"audio/*", "video/*", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-7z-compressed", "application/x-rar-compressed",
+}
+
+// Compressor compresses response of Handler with content coding negotiated by Accept-Encoding.
+// All codings registered to coding package are negotiated.
+// If nothing is acceptable, it responds 406 without calling Handler.
+type Compressor struct {
+	Handler Handler
+	// MinSize is the smallest body compressed. 0 means DefaultCompressMinSize.
+	MinSize int
+	// SkipTypes are media types not compressed. "type/*" matches any subtype. nil means DefaultSkipTypes.
+	SkipTypes []string
+	// Logger logs errors of compression. nil means standard logger.
+	Logger *log.Logger
+}
+
+// CompressHandler returns Compressor with default settings.
+func CompressHandler(h Handler) *Compressor {
+	return &Compressor{Handler: h}
+}
+
+func (c *Compressor) Handle(w ResponseWriter, req *request.Request) {
+	available := []header.ContentCoding{}
+	for _, name := range coding.Names() {
+		if name != header.CONTENT_CODING_IDENTITY.ToString() {
+			available = append(available, header.ContentCoding(name))
+		}
+	}
+	cc, ok := req.Headers.NegotiateContentCoding(available)
+	w.Header().AddVary("accept-encoding")
+	if !ok {
+		w.WriteHeader(406)
+		return
+	}
+
+	cw := &compressWriter{ResponseWriter: w, compressor: c, coding: cc}
+	c.Handler.Handle(cw, req)
+	if err := cw.finish(); err != nil {
+		// body may be truncated so the connection can't be reused
+		c.logger().Println(err)
+		fail(w, err)
+	}
+}
+
+func (c *Compressor) logger() *log.Logger {
+	if c.Logger == nil {
+		return log.Default()
+	}
+	return c.Logger
+}
+
+func (c *Compressor) minSize() int {
+	if c.MinSize == 0 {
+		return DefaultCompressMinSize
+	}
+	return c.MinSize
+}
+
+func (c *Compressor) skipped(contentType string) bool {
+	ct, err := header.ParseContentType(contentType)
+	if err != nil {
+		return false
+	}
+	mediaType := ct.MediaType()
+	skipTypes := c.SkipTypes
+	if skipTypes == nil {
+		skipTypes = DefaultSkipTypes
+	}
+	for _, s := range skipTypes {
+		if s == mediaType || (strings.HasSuffix(s, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(s, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter buffers body until it reaches MinSize and decides whether it's compressed.
+type compressWriter struct {
+	ResponseWriter
+	compressor  *Compressor
+	coding      header.ContentCoding
+	status      int
+	wroteHeader bool
+	decided     bool
+	encoder     io.WriteCloser
+	buf         []byte
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if !w.compressible() {
+		w.decide(false)
+		return
+	}
+	if cl, err := strconv.Atoi(w.Header().Get("Content-Length")); err == nil {
+		w.decide(cl >= w.compressor.minSize())
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(200)
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) >= w.compressor.minSize() {
+			if err := w.decide(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(b), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) Flush() error {
+	if !w.wroteHeader {
+		w.WriteHeader(200)
+	}
+	if !w.decided {
+		// streaming body is compressed regardless of its size
+		if err := w.decide(true); err != nil {
+			return err
+		}
+	}
+	if f, ok := w.encoder.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	return w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) finish() error {
+	if !w.wroteHeader {
+		w.WriteHeader(200)
+	}
+	if !w.decided {
+		if err := w.decide(len(w.buf) >= w.compressor.minSize()); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+func (w *compressWriter) fail(err error) {
+	fail(w.ResponseWriter, err)
+}
+
+// compressible returns false for response which must not be compressed regardless of its size.
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if w.coding == header.CONTENT_CODING_IDENTITY || w.status < 200 || w.status == 204 || w.status == 304 {
+		return false
+	}
+	// ranges are about selected representation so they can't be changed
+	if w.status == 206 || h.Get("Content-Range") != "" {
+		return false
+	}
+	return h.Get("Content-Encoding") == "" && !w.compressor.skipped(h.Get("Content-Type"))
+}
+
+// decide sends headers and buffered body.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	h := w.Header()
+	h.AddVary("accept-encoding")
+	if compress {
+		encoder, err := coding.NewWriter(w.coding.ToString(), bodyWriter{w.ResponseWriter})
+		if err != nil {
+			return err
+		}
+		w.encoder = encoder
+		h.Set("Content-Encoding", w.coding.ToString())
+		h.Del("Content-Length")
+		// compressed representation is not byte-for-byte same (RFC 9110 8.8.1)
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	b := w.buf
+	w.buf = nil
+	if w.encoder != nil {
+		_, err := w.encoder.Write(b)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(b)
+	return err
+}
+
+// bodyWriter hides methods of ResponseWriter other than Write from encoder.
+type bodyWriter struct {
+	w ResponseWriter
+}
+
+func (b bodyWriter) Write(p []byte) (int, error) {
+	return b.w.Write(p)
+}
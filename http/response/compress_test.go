@@ -0,0 +1,158 @@
+package response
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/inabajunmr/http11server/http/header"
+	"github.com/inabajunmr/http11server/http/request"
+)
+
+func compressResponse(t *testing.T, c *Compressor, acceptEncoding string) ([]byte, bool) {
+	called := false
+	handler := c.Handler
+	c.Handler = HandlerFunc(func(w ResponseWriter, req *request.Request) {
+		called = true
+		handler.Handle(w, req)
+	})
+	var conn bytes.Buffer
+	req := &request.Request{Headers: header.NewHeaders(header.Header{FieldName: "ACCEPT-ENCODING", FieldValue: acceptEncoding})}
+	w := NewConnWriter(&conn, req)
+	c.Handle(w, req)
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	return conn.Bytes(), called
+}
+
+func TestCompressor(t *testing.T) {
+	body := strings.Repeat("hello", 300)
+	tests := []struct {
+		name            string
+		handler         HandlerFunc
+		acceptEncoding  string
+		contentEncoding string
+		etag            string
+	}{
+		{name: "compressed", acceptEncoding: "gzip", contentEncoding: "gzip", etag: `W/"abc"`,
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Header().Set("ETag", `"abc"`)
+				w.Write([]byte(body))
+			}},
+		{name: "Content-Length", acceptEncoding: "gzip", contentEncoding: "gzip",
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Header().Set("Content-Length", "1500")
+				w.Write([]byte(body))
+			}},
+		{name: "streaming", acceptEncoding: "gzip", contentEncoding: "gzip",
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Write([]byte(body[:10]))
+				w.Flush()
+				w.Write([]byte(body[10:]))
+			}},
+		{name: "small", acceptEncoding: "gzip",
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Write([]byte(body[:1000]))
+			}},
+		{name: "small Content-Length", acceptEncoding: "gzip",
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Header().Set("Content-Length", "1000")
+				w.Write([]byte(body[:1000]))
+			}},
+		{name: "compressed media type", acceptEncoding: "gzip",
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Write([]byte(body))
+			}},
+		{name: "already encoded", acceptEncoding: "gzip", contentEncoding: "x-custom",
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Header().Set("Content-Encoding", "x-custom")
+				w.Write([]byte(body))
+			}},
+		{name: "partial content", acceptEncoding: "gzip",
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Header().Set("Content-Range", "bytes 0-1499/3000")
+				w.WriteHeader(206)
+				w.Write([]byte(body))
+			}},
+		{name: "identity preferred", acceptEncoding: "gzip;q=0.5, identity",
+			handler: func(w ResponseWriter, req *request.Request) {
+				w.Write([]byte(body))
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := compressResponse(t, &Compressor{Handler: tt.handler}, tt.acceptEncoding)
+			resp, respBody := readResponse(t, b)
+			if ce := resp.Header.Get("Content-Encoding"); ce != tt.contentEncoding {
+				t.Fatalf("Unexpected Content-Encoding: %v", ce)
+			}
+			if vary := resp.Header.Get("Vary"); vary != "accept-encoding" {
+				t.Errorf("Unexpected Vary: %v", vary)
+			}
+			if etag := resp.Header.Get("ETag"); etag != tt.etag {
+				t.Errorf("Unexpected ETag: %v", etag)
+			}
+			if tt.contentEncoding == "gzip" {
+				r, err := gzip.NewReader(bytes.NewReader(respBody))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if respBody, err = ioutil.ReadAll(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !strings.HasPrefix(body, string(respBody)) || len(respBody) < 1000 {
+				t.Errorf("Unexpected body length: %v", len(respBody))
+			}
+		})
+	}
+}
+
+func TestCompressor_NotAcceptable(t *testing.T) {
+	for _, ae := range []string{"identity;q=0", "br, identity;q=0", "*;q=0"} {
+		b, called := compressResponse(t, CompressHandler(EchoHandler), ae)
+		resp, _ := readResponse(t, b)
+		if resp.StatusCode != 406 {
+			t.Errorf("Unexpected status: %v", resp.StatusCode)
+		}
+		if called {
+			t.Error("Handler is called.")
+		}
+	}
+}
+
+// brokenWriter fails to write body.
+type brokenWriter struct {
+	*ConnWriter
+}
+
+func (w brokenWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("broken")
+}
+
+func TestCompressor_FinishError(t *testing.T) {
+	var conn, logs bytes.Buffer
+	req := &request.Request{Headers: header.NewHeaders(header.Header{FieldName: "ACCEPT-ENCODING", FieldValue: "gzip"})}
+	cw := NewConnWriter(&conn, req)
+	c := CompressHandler(HandlerFunc(func(w ResponseWriter, req *request.Request) {
+		w.Write([]byte("hello"))
+	}))
+	c.MinSize = 1
+	c.Logger = log.New(&logs, "", 0)
+	c.Handle(brokenWriter{cw}, req)
+	if err := cw.Finish(); err == nil {
+		t.Error("Finish must return error.")
+	}
+	if !cw.Closing() {
+		t.Error("Connection must be closed.")
+	}
+	if !strings.Contains(logs.String(), "broken") {
+		t.Errorf("Unexpected log: %v.", logs.String())
+	}
+}
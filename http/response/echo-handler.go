@@ -0,0 +1,259 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/header"
+	"github.com/inabajunmr/http11server/http/negotiation"
+	"github.com/inabajunmr/http11server/http/request"
+)
+
+type Echo struct {
+	Method        string   `xml:"method"`
+	RequestTarget string   `xml:"request_target"`
+	Version       string   `xml:"version"`
+	Headers       []string `xml:"headers"`
+	Trailers      []string `xml:"trailers,omitempty"`
+	Query         []Param  `xml:"query>param"`
+	Form          []Param  `xml:"form>param"`
+	Multipart     []Part   `xml:"multipart>part"`
+	Cookies       []Param  `xml:"cookies>cookie"`
+	Body          string   `xml:"body"`
+}
+
+// Param is a parameter of query or form or a cookie like <param name="a">1</param>.
+type Param struct {
+	Name  string `json:"name" xml:"name,attr"`
+	Value string `json:"value" xml:",chardata"`
+}
+
+// Part is a part of multipart/form-data. Its content is echoed as SHA-256 instead of body.
+type Part struct {
+	Name     string `json:"name" xml:"name"`
+	FileName string `json:"filename,omitempty" xml:"filename,omitempty"`
+	Size     int64  `json:"size" xml:"size"`
+	Type     string `json:"type" xml:"type"`
+	SHA256   string `json:"sha256" xml:"sha256"`
+}
+
+// echoResponseHeader sets headers of echo response.
+// body is not ranged body. If request has Range header, it's not splited yet.
+func echoResponseHeader(headers *header.Headers, r *request.Request, body []byte) {
+	headers.Set("Accept-Range", "bytes")
+
+	ranges, _ := r.Headers.GetRanges()
+	if len(ranges) >= 1 { // multiple ranges is unsuppored yet
+		s, e, ok := getRange(ranges[0].Start, ranges[0].End, body)
+		if !ok {
+			// Range header specify bigger value than body length
+			headers.Set("Content-Range", fmt.Sprintf("bytes */%v", len(body)))
+			headers.Set("Content-Length", "0")
+		} else {
+			headers.Set("Content-Range", fmt.Sprintf("bytes %v-%v/%v", s, e, len(body)))
+			headers.Set("Content-Length", strconv.Itoa(e-s))
+		}
+	} else {
+		headers.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+}
+
+// getRange returns start and end of range in body. ok is false if range is not satisfiable.
+func getRange(start *int, end *int, body []byte) (int, int, bool) {
+	rs := start
+	if rs == nil {
+		// suffix longer than body selects whole body (RFC 9110 14.1.3)
+		s := len(body) - *end
+		if s < 0 {
+			s = 0
+		}
+		rs = &s
+		length := len(body)
+		return *rs, length, *rs < length
+	}
+
+	re := end
+	if re == nil {
+		length := len(body)
+		re = &length
+	}
+
+	return *rs, *re, *rs < len(body) && *re <= len(body) && *rs <= *re
+}
+
+// echoTypes are media types of echo in order of preference.
+var echoTypes = []string{"application/json", "application/xml; charset=utf-8"}
+
+func echo(w ResponseWriter, req *request.Request) {
+	negotiated, ok := negotiation.ContentType(req.Headers, echoTypes)
+	negotiation.Apply(w.Header(), negotiated)
+	if !ok {
+		Error(w, &http.HTTPError{Status: 406, Msg: "Not Acceptable"})
+		return
+	}
+
+	// form is parsed before echoBody reads body since it fails with invalid form
+	if _, err := req.Query(); err != nil {
+		Error(w, err)
+		return
+	}
+	if _, err := req.Form(); err != nil {
+		Error(w, err)
+		return
+	}
+	// multipart body is read by parser, so echo body of it is empty
+	parts, err := echoParts(req)
+	if err != nil {
+		Error(w, err)
+		return
+	}
+
+	fullBody, err := echoBody(req, negotiated.Value, parts)
+	if err != nil {
+		Error(w, err)
+		return
+	}
+
+	echoResponseHeader(w.Header(), req, fullBody)
+	ranges, _ := req.Headers.GetRanges()
+
+	if len(ranges) >= 1 {
+		s, e, ok := getRange(ranges[0].Start, ranges[0].End, fullBody)
+		if !ok {
+			w.WriteHeader(416)
+			return
+		}
+		w.WriteHeader(206)
+		w.Write(fullBody[s:e])
+		return
+	}
+
+	w.WriteHeader(200)
+	w.Write(fullBody)
+}
+
+func echoBody(r *request.Request, mediaType string, parts []Part) ([]byte, error) {
+	body := []byte{}
+	if r.Body != nil {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	if ct, _ := header.ParseContentType(mediaType); ct.Subtype == "xml" {
+		return xml.MarshalIndent(echoXml(r, body, parts), "", " ")
+	}
+	return json.Marshal(echoJson(r, body, parts))
+}
+
+// echoParts returns parts of multipart/form-data request. It's nil for other requests.
+func echoParts(r *request.Request) ([]Part, error) {
+	if r.ContentType().MediaType() != "multipart/form-data" {
+		return nil, nil
+	}
+	form, err := r.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	parts := []Part{}
+	for _, p := range form.Parts {
+		f, err := p.Open()
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, Part{Name: p.Name, FileName: p.FileName, Size: p.Size,
+			Type: p.ContentType().ToString(), SHA256: hex.EncodeToString(hash.Sum(nil))})
+	}
+	return parts, nil
+}
+
+// echoJson returns echo of request. Optional sections are set only if request has them.
+func echoJson(r *request.Request, body []byte, parts []Part) map[string]interface{} {
+	echo := map[string]interface{}{
+		"method":         r.StartLine.Method.ToString(),
+		"request_target": r.StartLine.RequestTarget,
+		"version":        r.StartLine.Version.ToString(),
+		"headers":        toStrings(r.Headers),
+		"body":           string(body),
+	}
+	if r.Trailers.Len() != 0 {
+		echo["trailers"] = toStrings(r.Trailers)
+	}
+	if query, _ := r.Query(); len(query) != 0 {
+		echo["query"] = query
+	}
+	if form, _ := r.Form(); len(form) != 0 {
+		echo["form"] = form
+	}
+	if len(parts) != 0 {
+		echo["multipart"] = parts
+	}
+	if cookies := toCookies(r.Cookies()); len(cookies) != 0 {
+		echo["cookies"] = cookies
+	}
+	return echo
+}
+
+func echoXml(r *request.Request, body []byte, parts []Part) *Echo {
+	query, _ := r.Query()
+	form, _ := r.Form()
+	return &Echo{Method: r.StartLine.Method.ToString(),
+		RequestTarget: r.StartLine.RequestTarget,
+		Version:       r.StartLine.Version.ToString(),
+		Headers:       toStrings(r.Headers),
+		Trailers:      toStrings(r.Trailers),
+		Query:         toParams(query),
+		Form:          toParams(form),
+		Multipart:     parts,
+		Cookies:       toCookies(r.Cookies()),
+		Body:          string(body)}
+}
+
+func toCookies(cookies []header.Cookie) []Param {
+	params := []Param{}
+	for _, c := range cookies {
+		params = append(params, Param{Name: c.Name, Value: c.Value})
+	}
+	return params
+}
+
+// toParams returns parameters sorted by name. Values of the same name keep their order.
+func toParams(values url.Values) []Param {
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	params := []Param{}
+	for _, name := range names {
+		for _, v := range values[name] {
+			params = append(params, Param{Name: name, Value: v})
+		}
+	}
+	return params
+}
+
+func toStrings(headers header.Headers) []string {
+	strs := []string{}
+	for _, h := range headers.Fields() {
+		strs = append(strs, h.ToString())
+	}
+	return strs
+}
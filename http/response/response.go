@@ -1,42 +1,56 @@
 package response
 
 import (
-	"bytes"
-	"compress/gzip"
+	"errors"
 	"net"
+	"strings"
 
 	"github.com/inabajunmr/http11server/http"
-	"github.com/inabajunmr/http11server/http/header"
 	"github.com/inabajunmr/http11server/http/request"
 )
 
-type Response interface {
-	Response(conn net.Conn) error
+// Handler writes response for request.
+type Handler interface {
+	Handle(w ResponseWriter, req *request.Request)
 }
 
-func GetResponse(req request.Request) Response {
-	switch req.StartLine.Method {
-	case request.HEAD:
-		return HeadResponse{Version: http.HTTP11, StatusCode: 200, ReasonPhrase: "OK", Request: req}
-	case request.OPTIONS:
-		return OptionsResponse{Version: http.HTTP11, Request: req}
-	default:
-		return EchoResponse{Version: http.HTTP11, StatusCode: 200, ReasonPhrase: "OK", Request: req}
-	}
+// BodyLimiter is implemented by handler limiting request body size per request.
+// 0 means server's limit.
+type BodyLimiter interface {
+	MaxBodyBytes(req *request.Request) int64
+}
+
+type HandlerFunc func(w ResponseWriter, req *request.Request)
+
+func (f HandlerFunc) Handle(w ResponseWriter, req *request.Request) {
+	f(w, req)
 }
 
-func compress(body []byte, acceptEncodings []header.AcceptEncoding) []byte {
-	for _, ae := range acceptEncodings {
-		if ae.Coding == header.CONTENT_CODING_GZIP {
-			var b bytes.Buffer
-			writer := gzip.NewWriter(&b)
-			writer.Write(body) // TODO error
-			writer.Flush()
-			writer.Close()
-			return b.Bytes()
-		} else if ae.Coding == header.CONTENT_CODING_IDENTITY {
-			return body
-		}
+// EchoHandler responds request itself as body.
+var EchoHandler = HandlerFunc(echo)
+
+// OptionsHandler responds 204 with Allow header.
+func OptionsHandler(allow []string) Handler {
+	return HandlerFunc(func(w ResponseWriter, req *request.Request) {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		w.WriteHeader(204)
+	})
+}
+
+// Error responds err.
+// HTTPError responds its status, timeout responds 408 with Connection: close and other errors respond 500.
+// If status is already written, it does nothing.
+func Error(w ResponseWriter, err error) {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		w.Header().Set("Connection", "close")
+		w.WriteHeader(408)
+		return
+	}
+	var httpErr *http.HTTPError
+	if errors.As(err, &httpErr) {
+		w.WriteHeader(httpErr.Status)
+		return
 	}
-	return []byte{} // TODO error
+	w.WriteHeader(500)
 }
@@ -0,0 +1,362 @@
+package response
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/inabajunmr/http11server/http"
+	"github.com/inabajunmr/http11server/http/coding"
+	"github.com/inabajunmr/http11server/http/header"
+	"github.com/inabajunmr/http11server/http/request"
+)
+
+// ResponseWriter is used by handler to write response.
+type ResponseWriter interface {
+	// Header returns headers to be sent by WriteHeader.
+	Header() *header.Headers
+	// WriteHeader sends status line and headers. Header changes after this are ignored.
+	WriteHeader(status int)
+	// Write writes body. It calls WriteHeader(200) if WriteHeader is not called yet.
+	Write(b []byte) (int, error)
+	// Flush sends buffered body to client.
+	Flush() error
+	// Trailer returns trailer fields sent after body.
+	// Fields must be declared by Trailer header before WriteHeader.
+	// They are sent only if request has TE: trailers.
+	Trailer() *header.Headers
+}
+
+// ErrContentLength is returned when body written by handler doesn't match its Content-Length.
+var ErrContentLength = errors.New("response: body length doesn't match Content-Length")
+
+// DefaultBufferSize is max size of body buffered to respond with Content-Length.
+// Larger body without Content-Length is sent with Transfer-Encoding: chunked.
+const DefaultBufferSize = 4 * 1024
+
+// ConnWriter is ResponseWriter writing to connection.
+//
+// If handler sets Content-Length, body is written as it is.
+// Body longer or shorter than it fails the response and the connection is closed.
+// Otherwise body is buffered up to BufferSize and Content-Length is set from it when handler finishes.
+// If body gets larger than BufferSize or Flush is called, it's sent with Transfer-Encoding: chunked.
+// If trailers are declared and client accepts them, it's always sent with Transfer-Encoding: chunked.
+type ConnWriter struct {
+	BufferSize int
+	// TransferCoding enables encoding body with transfer coding accepted by TE header like "gzip, chunked".
+	// It's not applied to response with Content-Length.
+	TransferCoding bool
+
+	conn        io.Writer
+	req         *request.Request
+	header      header.Headers
+	trailer     header.Headers
+	sendTrailer bool
+	coding      string
+	encoder     io.WriteCloser
+	status      int
+	wroteHeader bool
+	headersSent bool
+	chunked     bool
+	buf         bytes.Buffer
+	written     int64
+	// contentLength is Content-Length of sent headers. It's -1 if body isn't delimited by it.
+	contentLength int64
+	// sent is length of body sent with Content-Length.
+	sent int64
+	err  error
+}
+
+// NewConnWriter returns writer for response to req. req can be nil for error before request is parsed.
+func NewConnWriter(conn io.Writer, req *request.Request) *ConnWriter {
+	return &ConnWriter{BufferSize: DefaultBufferSize, conn: conn, req: req, header: header.Headers{}, trailer: header.Headers{}}
+}
+
+func (w *ConnWriter) Header() *header.Headers {
+	return &w.header
+}
+
+func (w *ConnWriter) Trailer() *header.Headers {
+	return &w.trailer
+}
+
+func (w *ConnWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if w.req != nil && w.req.Close {
+		w.header.Set("Connection", "close")
+	}
+
+	// trailer requires chunked
+	if trailer, _ := w.header.GetTrailer(); len(trailer) != 0 {
+		if w.req != nil && w.req.Headers.AcceptsTrailers() && w.header.Get("Content-Length") == "" && w.bodyAllowed() {
+			w.sendTrailer = true
+		} else {
+			w.header.Del("Trailer")
+		}
+	}
+
+	if w.TransferCoding && w.req != nil && w.header.Get("Content-Length") == "" && w.bodyAllowed() {
+		// invalid TE is disregarded
+		tes, _ := w.req.Headers.GetAcceptTransferEncodings()
+		if len(tes) != 0 && tes[0] != header.TRANSFER_ENCODING_IDENTITY {
+			w.coding = tes[0].ToString()
+		}
+	}
+}
+
+func (w *ConnWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(200)
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	if !w.bodyAllowed() {
+		w.written += int64(len(b))
+		return len(b), nil
+	}
+
+	if w.headersSent {
+		if w.chunked {
+			return len(b), w.writeBody(b)
+		}
+		return w.writeFixed(b)
+	}
+
+	if w.header.Get("Content-Length") != "" {
+		if err := w.sendHeaders(); err != nil {
+			return 0, err
+		}
+		return w.writeFixed(b)
+	}
+
+	w.buf.Write(b)
+	if w.buf.Len() > w.BufferSize {
+		if err := w.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *ConnWriter) Flush() error {
+	if !w.wroteHeader {
+		w.WriteHeader(200)
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if !w.headersSent {
+		if w.header.Get("Content-Length") == "" && w.bodyAllowed() {
+			w.chunked = true
+			if w.coding == "" {
+				w.header.Set("Transfer-Encoding", "chunked")
+			} else {
+				w.header.Set("Transfer-Encoding", w.coding+", chunked")
+				w.encoder, _ = coding.NewWriter(w.coding, chunkWriter{w})
+			}
+		}
+		if err := w.sendHeaders(); err != nil {
+			return err
+		}
+	}
+	if w.buf.Len() != 0 {
+		b := w.buf.Bytes()
+		w.buf.Reset()
+		if !w.chunked {
+			_, err := w.writeFixed(b)
+			return err
+		}
+		if err := w.writeBody(b); err != nil {
+			return err
+		}
+	}
+	if f, ok := w.encoder.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Finish completes response after handler. Server calls it.
+func (w *ConnWriter) Finish() error {
+	if !w.wroteHeader {
+		w.WriteHeader(200)
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if !w.headersSent && !w.sendTrailer && w.coding == "" {
+		if w.header.Get("Content-Length") == "" && w.status != 204 && w.status != 304 && w.status >= 200 {
+			length := int64(w.buf.Len())
+			if w.isHead() {
+				length = w.written
+			}
+			w.header.Set("Content-Length", strconv.FormatInt(length, 10))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return w.checkLength()
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Close(); err != nil {
+			return err
+		}
+	}
+	if w.chunked {
+		_, err := w.writeConn([]byte("0\r\n" + w.trailerSection() + "\r\n"))
+		return err
+	}
+	return w.checkLength()
+}
+
+// writeFixed writes body delimited by Content-Length.
+// Bytes exceeding it aren't sent since client would read them as next response.
+func (w *ConnWriter) writeFixed(b []byte) (int, error) {
+	if w.contentLength >= 0 && int64(len(b)) > w.contentLength-w.sent {
+		w.err = fmt.Errorf("%w: body exceeds Content-Length %v", ErrContentLength, w.contentLength)
+		return 0, w.err
+	}
+	n, err := w.writeConn(b)
+	w.sent += int64(n)
+	return n, err
+}
+
+// checkLength fails the response if body is shorter than Content-Length.
+// Client would wait for rest of body or read next response as a part of it.
+func (w *ConnWriter) checkLength() error {
+	if w.contentLength >= 0 && w.sent < w.contentLength {
+		w.err = fmt.Errorf("%w: %v bytes are written for Content-Length %v", ErrContentLength, w.sent, w.contentLength)
+		return w.err
+	}
+	return nil
+}
+
+// trailerSection returns trailer fields declared by Trailer header.
+func (w *ConnWriter) trailerSection() string {
+	if !w.sendTrailer {
+		return ""
+	}
+	section := ""
+	for _, t := range w.trailer.Fields() {
+		if w.header.IsDeclaredTrailer(t.FieldName) && !header.IsForbiddenTrailer(t.FieldName) {
+			section += t.ToString() + "\r\n"
+		}
+	}
+	return section
+}
+
+// failer is implemented by ResponseWriter which can record error of wrapping writer.
+type failer interface {
+	fail(err error)
+}
+
+// fail marks response of w as failed so that the connection is closed after it.
+// w not implementing failer gets Connection: close which works only before headers are sent.
+func fail(w ResponseWriter, err error) {
+	if f, ok := w.(failer); ok {
+		f.fail(err)
+		return
+	}
+	w.Header().Set("Connection", "close")
+}
+
+func (w *ConnWriter) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// Closing returns true if connection should be closed after the response.
+func (w *ConnWriter) Closing() bool {
+	return w.err != nil || strings.EqualFold(w.header.Get("Connection"), "close")
+}
+
+// Status returns status written by handler.
+func (w *ConnWriter) Status() int {
+	return w.status
+}
+
+func (w *ConnWriter) sendHeaders() error {
+	w.headersSent = true
+	w.contentLength = -1
+	if !w.chunked && w.bodyAllowed() {
+		if cl, err := strconv.ParseInt(w.header.Get("Content-Length"), 10, 64); err == nil {
+			w.contentLength = cl
+		}
+	}
+	var b bytes.Buffer
+	b.WriteString(fmt.Sprintf("%v %v %v\r\n", http.HTTPVersion(http.HTTP11).ToString(), w.status, http.StatusText(w.status)))
+	if w.header.Get("Date") == "" {
+		b.WriteString(fmt.Sprintf("Date: %v\r\n", time.Now().UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT")))
+	}
+	for _, h := range w.header.Fields() {
+		b.WriteString(h.ToString() + "\r\n")
+	}
+	b.WriteString("\r\n")
+	_, err := w.writeConn(b.Bytes())
+	return err
+}
+
+// writeBody writes chunked body encoded with transfer coding.
+func (w *ConnWriter) writeBody(b []byte) error {
+	if w.encoder != nil {
+		_, err := w.encoder.Write(b)
+		return err
+	}
+	return w.writeChunk(b)
+}
+
+// chunkWriter writes encoded body as chunks.
+type chunkWriter struct {
+	w *ConnWriter
+}
+
+func (c chunkWriter) Write(b []byte) (int, error) {
+	return len(b), c.w.writeChunk(b)
+}
+
+func (w *ConnWriter) writeChunk(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	if _, err := w.writeConn([]byte(fmt.Sprintf("%x\r\n", len(b)))); err != nil {
+		return err
+	}
+	if _, err := w.writeConn(b); err != nil {
+		return err
+	}
+	_, err := w.writeConn([]byte("\r\n"))
+	return err
+}
+
+func (w *ConnWriter) writeConn(b []byte) (int, error) {
+	n, err := w.conn.Write(b)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *ConnWriter) isHead() bool {
+	return w.req != nil && w.req.StartLine.Method == request.HEAD
+}
+
+// bodyAllowed returns false for response which must not have body.
+func (w *ConnWriter) bodyAllowed() bool {
+	if w.isHead() {
+		return false
+	}
+	return w.status >= 200 && w.status != 204 && w.status != 304
+}
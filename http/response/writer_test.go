@@ -0,0 +1,291 @@
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	ghttp "net/http"
+	"net/http/httputil"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/inabajunmr/http11server/http/coding"
+	"github.com/inabajunmr/http11server/http/header"
+	"github.com/inabajunmr/http11server/http/request"
+)
+
+func readResponse(t *testing.T, b []byte) (*ghttp.Response, []byte) {
+	resp, err := ghttp.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func TestConnWriter_ContentLength(t *testing.T) {
+	var conn bytes.Buffer
+	w := NewConnWriter(&conn, &request.Request{})
+	w.Write([]byte("hello"))
+	w.Write([]byte("world"))
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, body := readResponse(t, conn.Bytes())
+	if resp.StatusCode != 200 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+	if resp.ContentLength != 10 {
+		t.Errorf("Unexpected Content-Length: %v", resp.ContentLength)
+	}
+	if len(resp.TransferEncoding) != 0 {
+		t.Errorf("Unexpected Transfer-Encoding: %v", resp.TransferEncoding)
+	}
+	if resp.Header.Get("Date") == "" {
+		t.Error("Missing Date header.")
+	}
+	if string(body) != "helloworld" {
+		t.Errorf("Unexpected body: %v", string(body))
+	}
+}
+
+func TestConnWriter_Chunked(t *testing.T) {
+	var conn bytes.Buffer
+	w := NewConnWriter(&conn, &request.Request{})
+	w.BufferSize = 8
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(201)
+	w.Write([]byte("hello"))
+	w.Write([]byte("world"))
+	w.Write([]byte("!!"))
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, body := readResponse(t, conn.Bytes())
+	if resp.StatusCode != 201 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Unexpected Transfer-Encoding: %v", resp.TransferEncoding)
+	}
+	if resp.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("Unexpected Content-Type: %v", resp.Header.Get("Content-Type"))
+	}
+	if string(body) != "helloworld!!" {
+		t.Errorf("Unexpected body: %v", string(body))
+	}
+}
+
+func TestConnWriter_Flush(t *testing.T) {
+	var conn bytes.Buffer
+	w := NewConnWriter(&conn, &request.Request{})
+	w.Write([]byte("hello"))
+	w.Flush()
+	if !strings.HasSuffix(conn.String(), "5\r\nhello\r\n") {
+		t.Errorf("Unexpected response: %v", conn.String())
+	}
+	w.Write([]byte("world"))
+	w.Finish()
+
+	resp, body := readResponse(t, conn.Bytes())
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Unexpected Transfer-Encoding: %v", resp.TransferEncoding)
+	}
+	if string(body) != "helloworld" {
+		t.Errorf("Unexpected body: %v", string(body))
+	}
+}
+
+func TestConnWriter_ExplicitContentLength(t *testing.T) {
+	var conn bytes.Buffer
+	w := NewConnWriter(&conn, &request.Request{})
+	w.BufferSize = 1
+	w.Header().Set("Content-Length", "10")
+	w.Write([]byte("hello"))
+	w.Write([]byte("world"))
+	w.Finish()
+
+	resp, body := readResponse(t, conn.Bytes())
+	if resp.ContentLength != 10 {
+		t.Errorf("Unexpected Content-Length: %v", resp.ContentLength)
+	}
+	if string(body) != "helloworld" {
+		t.Errorf("Unexpected body: %v", string(body))
+	}
+}
+
+func TestConnWriter_ContentLengthMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     []string
+		expected string
+	}{
+		{name: "longer", body: []string{"hello\r\nHTTP/1.1 200 OK\r\n"}, expected: ""},
+		{name: "longer in second write", body: []string{"he", "llo"}, expected: "he"},
+		{name: "shorter", body: []string{"he"}, expected: "he"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conn bytes.Buffer
+			w := NewConnWriter(&conn, &request.Request{})
+			w.Header().Set("Content-Length", "3")
+			for _, b := range tt.body {
+				w.Write([]byte(b))
+			}
+			if err := w.Finish(); !errors.Is(err, ErrContentLength) {
+				t.Errorf("Unexpected error: %v.", err)
+			}
+			if !w.Closing() {
+				t.Error("Closing is expected.")
+			}
+			if !strings.HasSuffix(conn.String(), "\r\n\r\n"+tt.expected) {
+				t.Errorf("Unexpected response: %v", conn.String())
+			}
+		})
+	}
+}
+
+func TestConnWriter_Head(t *testing.T) {
+	var conn bytes.Buffer
+	w := NewConnWriter(&conn, &request.Request{StartLine: request.StartLine{Method: request.HEAD}})
+	w.Write([]byte("helloworld"))
+	w.Finish()
+
+	if !strings.Contains(conn.String(), "Content-Length: 10\r\n") {
+		t.Errorf("Unexpected response: %v", conn.String())
+	}
+	if !strings.HasSuffix(conn.String(), "\r\n\r\n") {
+		t.Errorf("Unexpected response: %v", conn.String())
+	}
+}
+
+func TestConnWriter_Close(t *testing.T) {
+	var conn bytes.Buffer
+	w := NewConnWriter(&conn, &request.Request{Close: true})
+	w.WriteHeader(204)
+	w.Finish()
+
+	resp, _ := readResponse(t, conn.Bytes())
+	if !resp.Close {
+		t.Error("Connection: close is expected.")
+	}
+	if !w.Closing() {
+		t.Error("Closing is expected.")
+	}
+	if strings.Contains(conn.String(), "Content-Length") {
+		t.Errorf("Unexpected response: %v", conn.String())
+	}
+}
+
+func TestConnWriter_Trailer(t *testing.T) {
+	tests := []struct {
+		name     string
+		te       string
+		expected string
+	}{
+		{name: "TE: trailers", te: "trailers", expected: "abc"},
+		{name: "no TE", te: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &request.Request{}
+			if tt.te != "" {
+				req.Headers.Add("TE", tt.te)
+			}
+			var conn bytes.Buffer
+			w := NewConnWriter(&conn, req)
+			w.Header().Set("Trailer", "X-Digest")
+			w.WriteHeader(200)
+			w.Write([]byte("hello"))
+			w.Trailer().Set("X-Digest", "abc")
+			w.Trailer().Set("X-Undeclared", "abc")
+			w.Finish()
+
+			resp, body := readResponse(t, conn.Bytes())
+			if string(body) != "hello" {
+				t.Errorf("Unexpected body: %v", string(body))
+			}
+			if resp.Trailer.Get("X-Digest") != tt.expected {
+				t.Errorf("Unexpected trailer: %v", resp.Trailer)
+			}
+			if resp.Trailer.Get("X-Undeclared") != "" {
+				t.Errorf("Unexpected trailer: %v", resp.Trailer)
+			}
+			if tt.expected == "" && resp.Header.Get("Trailer") != "" {
+				t.Errorf("Unexpected Trailer header: %v", resp.Header.Get("Trailer"))
+			}
+		})
+	}
+}
+
+func TestConnWriter_TransferCoding(t *testing.T) {
+	tests := []struct {
+		te             string
+		enabled        bool
+		contentLength  bool
+		expectedCoding string
+	}{
+		{te: "gzip;q=0.5, deflate", enabled: true, expectedCoding: "deflate, chunked"},
+		{te: "trailers, compress", enabled: true, expectedCoding: "compress, chunked"},
+		{te: "identity, gzip;q=0.5", enabled: true, expectedCoding: ""},
+		{te: "gzip;q=0", enabled: true, expectedCoding: ""},
+		{te: "gzip", enabled: false, expectedCoding: ""},
+		{te: "gzip", enabled: true, contentLength: true, expectedCoding: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.te, func(t *testing.T) {
+			var conn bytes.Buffer
+			req := &request.Request{Headers: header.NewHeaders(header.Header{FieldName: "TE", FieldValue: tt.te})}
+			w := NewConnWriter(&conn, req)
+			w.TransferCoding = tt.enabled
+			if tt.contentLength {
+				w.Header().Set("Content-Length", "20")
+			}
+			w.Write([]byte("helloworld"))
+			w.Write([]byte("helloworld"))
+			if err := w.Finish(); err != nil {
+				t.Fatal(err)
+			}
+
+			reader := textproto.NewReader(bufio.NewReader(&conn))
+			if _, err := reader.ReadLine(); err != nil {
+				t.Fatal(err)
+			}
+			headers, err := reader.ReadMIMEHeader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.expectedCoding == "" {
+				if te := headers.Get("Transfer-Encoding"); te != "" {
+					t.Errorf("Unexpected Transfer-Encoding: %v", te)
+				}
+				return
+			}
+			if te := headers.Get("Transfer-Encoding"); te != tt.expectedCoding {
+				t.Fatalf("Unexpected Transfer-Encoding: %v", te)
+			}
+			if cl := headers.Get("Content-Length"); cl != "" {
+				t.Errorf("Unexpected Content-Length: %v", cl)
+			}
+			decoder, err := coding.NewReader(strings.Split(tt.expectedCoding, ",")[0], httputil.NewChunkedReader(reader.R))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ioutil.ReadAll(decoder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != "helloworldhelloworld" {
+				t.Errorf("Unexpected body: %v", string(body))
+			}
+		})
+	}
+}
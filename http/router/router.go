@@ -0,0 +1,188 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/inabajunmr/http11server/http/request"
+	"github.com/inabajunmr/http11server/http/response"
+)
+
+// Router dispatches request to handler by method and path.
+//
+// Pattern is a path like "/users/:id/*rest".
+// ":name" matches one segment and "*name" matches rest of path (it must be last segment).
+// When multiple routes match, static segment wins over ":name" and ":name" wins over "*name".
+type Router struct {
+	routes []*Route
+}
+
+type Route struct {
+	Method  request.HTTPMethod
+	Pattern string
+	Handler response.Handler
+	// MaxBodyBytes limits request body size for this route. 0 means server's limit.
+	MaxBodyBytes int64
+	segments     []string
+}
+
+type SegmentKind int
+
+const (
+	SEGMENT_WILDCARD SegmentKind = iota
+	SEGMENT_PARAM
+	SEGMENT_STATIC
+)
+
+func NewRouter() *Router {
+	return &Router{routes: []*Route{}}
+}
+
+// Add registers handler for method and pattern.
+func (r *Router) Add(method request.HTTPMethod, pattern string, handler response.Handler) *Route {
+	route := &Route{Method: method, Pattern: pattern, Handler: handler, segments: splitPath(pattern)}
+	r.routes = append(r.routes, route)
+	return route
+}
+
+func (r *Router) AddFunc(method request.HTTPMethod, pattern string, f func(w response.ResponseWriter, req *request.Request)) *Route {
+	return r.Add(method, pattern, response.HandlerFunc(f))
+}
+
+// Handle dispatches request to matched route.
+// If no route matches the path, it returns 404.
+// If routes match the path but not the method, it returns 405 with Allow header.
+// OPTIONS without registered route returns Allow header built from routes.
+func (r *Router) Handle(w response.ResponseWriter, req *request.Request) {
+	path := req.StartLine.Target.Path
+
+	route, params := r.match(req.StartLine.Method, path)
+	if route != nil {
+		req.PathParams = params
+		route.Handler.Handle(w, req)
+		return
+	}
+
+	allow := r.allow(path)
+	if len(allow) == 0 {
+		w.WriteHeader(404)
+		return
+	}
+	if req.StartLine.Method == request.OPTIONS {
+		response.OptionsHandler(allow).Handle(w, req)
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+	w.WriteHeader(405)
+}
+
+// MaxBodyBytes returns body size limit of route matched to req.
+func (r *Router) MaxBodyBytes(req *request.Request) int64 {
+	route, _ := r.match(req.StartLine.Method, req.StartLine.Target.Path)
+	if route == nil {
+		return 0
+	}
+	return route.MaxBodyBytes
+}
+
+func (r *Router) match(method request.HTTPMethod, path string) (*Route, map[string]string) {
+	var matched *Route
+	var matchedParams map[string]string
+	for _, route := range r.routes {
+		if route.Method != method {
+			continue
+		}
+		params, ok := route.match(path)
+		if !ok {
+			continue
+		}
+		if matched == nil || route.moreSpecificThan(matched) {
+			matched = route
+			matchedParams = params
+		}
+	}
+	return matched, matchedParams
+}
+
+// allow returns methods of routes matching path.
+func (r *Router) allow(path string) []string {
+	methods := []string{}
+	for _, route := range r.routes {
+		if _, ok := route.match(path); !ok {
+			continue
+		}
+		if !contains(methods, route.Method.ToString()) {
+			methods = append(methods, route.Method.ToString())
+		}
+	}
+	if len(methods) != 0 && !contains(methods, request.OPTIONS.ToString()) {
+		methods = append(methods, request.OPTIONS.ToString())
+	}
+	return methods
+}
+
+func (route *Route) match(path string) (map[string]string, bool) {
+	params := map[string]string{}
+	segments := splitPath(path)
+	for i, s := range route.segments {
+		switch kindOf(s) {
+		case SEGMENT_WILDCARD:
+			params[s[1:]] = strings.Join(segments[min(i, len(segments)):], "/")
+			return params, true
+		case SEGMENT_PARAM:
+			if i >= len(segments) || segments[i] == "" {
+				return nil, false
+			}
+			params[s[1:]] = segments[i]
+		case SEGMENT_STATIC:
+			if i >= len(segments) || segments[i] != s {
+				return nil, false
+			}
+		}
+	}
+	if len(route.segments) != len(segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+func (route *Route) moreSpecificThan(other *Route) bool {
+	for i := 0; i < len(route.segments) && i < len(other.segments); i++ {
+		k1 := kindOf(route.segments[i])
+		k2 := kindOf(other.segments[i])
+		if k1 != k2 {
+			return k1 > k2
+		}
+	}
+	return len(route.segments) > len(other.segments)
+}
+
+func kindOf(segment string) SegmentKind {
+	if strings.HasPrefix(segment, "*") {
+		return SEGMENT_WILDCARD
+	}
+	if strings.HasPrefix(segment, ":") {
+		return SEGMENT_PARAM
+	}
+	return SEGMENT_STATIC
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+func contains(vs []string, v string) bool {
+	for _, s := range vs {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
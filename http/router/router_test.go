@@ -0,0 +1,138 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/inabajunmr/http11server/http/header"
+	"github.com/inabajunmr/http11server/http/request"
+	"github.com/inabajunmr/http11server/http/response"
+)
+
+type recorder struct {
+	header header.Headers
+	status int
+	name   string
+	params map[string]string
+}
+
+func (r *recorder) Header() *header.Headers {
+	return &r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (r *recorder) Flush() error {
+	return nil
+}
+
+func (r *recorder) Trailer() *header.Headers {
+	return &header.Headers{}
+}
+
+func named(name string) response.Handler {
+	return response.HandlerFunc(func(w response.ResponseWriter, req *request.Request) {
+		w.(*recorder).name = name
+		w.(*recorder).params = req.PathParams
+	})
+}
+
+func newRequest(method request.HTTPMethod, target string) *request.Request {
+	startLine, err := request.ParseStartLine(method.ToString() + " " + target + " HTTP/1.1")
+	if err != nil {
+		panic(err)
+	}
+	return &request.Request{StartLine: *startLine}
+}
+
+func TestHandle_Match(t *testing.T) {
+	r := NewRouter()
+	r.Add(request.GET, "/", named("root"))
+	r.Add(request.GET, "/users/:id", named("user"))
+	r.Add(request.GET, "/users/me", named("me"))
+	r.Add(request.GET, "/static/*file", named("static"))
+	r.Add(request.POST, "/users/:id", named("post-user"))
+
+	tests := []struct {
+		method request.HTTPMethod
+		target string
+		name   string
+		params map[string]string
+	}{
+		{request.GET, "/", "root", map[string]string{}},
+		{request.GET, "/users/10", "user", map[string]string{"id": "10"}},
+		{request.GET, "/users/10?a=b", "user", map[string]string{"id": "10"}},
+		{request.GET, "http://example.com/users/10?a=b", "user", map[string]string{"id": "10"}},
+		{request.GET, "/users/me", "me", map[string]string{}},
+		{request.POST, "/users/me", "post-user", map[string]string{"id": "me"}},
+		{request.GET, "/static/css/a.css", "static", map[string]string{"file": "css/a.css"}},
+		{request.GET, "/static", "static", map[string]string{"file": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			res := &recorder{}
+			r.Handle(res, newRequest(tt.method, tt.target))
+			if res.name != tt.name {
+				t.Errorf("Unexpected route: %v", res.name)
+			}
+			if len(res.params) != len(tt.params) {
+				t.Errorf("Unexpected params: %v", res.params)
+			}
+			for k, v := range tt.params {
+				if res.params[k] != v {
+					t.Errorf("Unexpected params: %v", res.params)
+				}
+			}
+		})
+	}
+}
+
+func TestHandle_NotFound(t *testing.T) {
+	r := NewRouter()
+	r.Add(request.GET, "/users/:id", named("user"))
+
+	for _, target := range []string{"/", "/users", "/users/", "/users/1/2"} {
+		res := &recorder{}
+		r.Handle(res, newRequest(request.GET, target))
+		if res.status != 404 {
+			t.Errorf("Unexpected status: %v", res.status)
+		}
+	}
+}
+
+func TestHandle_MethodNotAllowed(t *testing.T) {
+	r := NewRouter()
+	r.Add(request.GET, "/users/:id", named("user"))
+	r.Add(request.DELETE, "/users/:id", named("delete-user"))
+	r.Add(request.POST, "/users", named("post-user"))
+
+	res := &recorder{}
+	r.Handle(res, newRequest(request.PUT, "/users/1"))
+	if res.status != 405 {
+		t.Errorf("Unexpected status: %v", res.status)
+	}
+	if res.header.Get("Allow") != "GET, DELETE, OPTIONS" {
+		t.Errorf("Unexpected headers: %v", res.header.ToString())
+	}
+}
+
+func TestHandle_Options(t *testing.T) {
+	r := NewRouter()
+	r.Add(request.GET, "/*", named("get"))
+	r.Add(request.POST, "/*", named("post"))
+
+	res := &recorder{}
+	r.Handle(res, newRequest(request.OPTIONS, "/a"))
+	if res.status != 204 {
+		t.Errorf("Unexpected status: %v", res.status)
+	}
+	if res.header.Get("Allow") != "GET, POST, OPTIONS" {
+		t.Errorf("Unexpected allow: %v", res.header.Get("Allow"))
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// conn sets deadline for each phase of request.
+type conn struct {
+	net.Conn
+}
+
+func (c *conn) setReadTimeout(d time.Duration) {
+	if d == 0 {
+		c.SetReadDeadline(time.Time{})
+		return
+	}
+	c.SetReadDeadline(time.Now().Add(d))
+}
+
+func (c *conn) setWriteTimeout(d time.Duration) {
+	if d == 0 {
+		c.SetWriteDeadline(time.Time{})
+		return
+	}
+	c.SetWriteDeadline(time.Now().Add(d))
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
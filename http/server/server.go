@@ -2,65 +2,295 @@ package server
 
 import (
 	"bufio"
-	"fmt"
+	"context"
+	"errors"
 	"io"
 	"log"
 	"net"
-	"os"
+	"sync"
+	"time"
 
 	"github.com/inabajunmr/http11server/http"
 	"github.com/inabajunmr/http11server/http/request"
 	"github.com/inabajunmr/http11server/http/response"
+	"github.com/inabajunmr/http11server/http/router"
 )
 
-var listener *net.TCPListener
+// ErrServerClosed is returned by Serve and ListenAndServe after Close or Shutdown.
+var ErrServerClosed = errors.New("server closed")
 
-var PORT int
+type Server struct {
+	// Addr is TCP address to listen like ":80". Empty means ":80".
+	Addr string
+	// Handler handles requests. nil means DefaultRouter().
+	Handler response.Handler
+	// Logger logs requests. nil means standard logger.
+	Logger *log.Logger
+	// MaxConns limits number of connections served at the same time. 0 means unlimited.
+	MaxConns int
 
-func Serve(port int) {
+	// ReadHeaderTimeout limits time to read request line and headers. Exceeding it responds 408.
+	ReadHeaderTimeout time.Duration
+	// ReadBodyTimeout limits time to read request body. Exceeding it responds 408.
+	ReadBodyTimeout time.Duration
+	// WriteTimeout limits time to write response. Exceeding it closes connection.
+	WriteTimeout time.Duration
+	// IdleTimeout limits time to wait for next request on keep-alive connection.
+	// Exceeding it closes connection. 0 means ReadHeaderTimeout.
+	// Each timeout is unlimited if it's 0.
+	IdleTimeout time.Duration
 
-	service := fmt.Sprintf(":%v", port)
-	tcpAddr, err := net.ResolveTCPAddr("tcp4", service)
-	checkError(err)
-	listener, err = net.ListenTCP("tcp4", tcpAddr)
-	checkError(err)
-	PORT = listener.Addr().(*net.TCPAddr).Port
-	log.Printf("LISTEN PORT:%v", PORT)
+	// Limits restricts size of request line, headers and body.
+	Limits request.Limits
+	// TransferCoding enables compressing responses with transfer coding requested by TE header.
+	TransferCoding bool
 
-	for {
-		conn, _ := listener.AcceptTCP()
-		reader := bufio.NewReader(conn)
-		go processRequest(conn, reader)
+	mu         sync.Mutex
+	listener   net.Listener
+	closed     bool
+	inShutdown bool
+	conns      map[net.Conn]struct{}
+}
+
+const shutdownPollInterval = 10 * time.Millisecond
+
+// maxDiscardBytes is max size of unread body discarded to keep connection.
+// Connection having larger unread body is closed.
+const maxDiscardBytes = 256 * 1024
+
+// DefaultRouter returns router which echoes request for any path.
+// Echo is compressed regardless of its size.
+func DefaultRouter() *router.Router {
+	echo := &response.Compressor{Handler: response.EchoHandler, MinSize: 1}
+	r := router.NewRouter()
+	r.Add(request.GET, "/*", echo)
+	r.Add(request.POST, "/*", echo)
+	r.Add(request.HEAD, "/*", echo)
+	return r
+}
+
+func (s *Server) ListenAndServe() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":80"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
 	}
+	return s.Serve(l)
 }
 
-func processRequest(conn net.Conn, reader *bufio.Reader) {
+// Serve accepts connections on l and serves requests on each connection.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+	s.logger().Printf("LISTEN:%v", l.Addr())
+
+	var sem chan struct{}
+	if s.MaxConns > 0 {
+		sem = make(chan struct{}, s.MaxConns)
+	}
+
+	handler := s.Handler
+	if handler == nil {
+		handler = DefaultRouter()
+	}
+
 	for {
-		req, err := request.ParseRequest(reader)
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		conn, err := l.Accept()
 		if err != nil {
-			if handleError(conn, err) {
-				log.Println("Close")
-				conn.Close()
-				return
+			if s.isClosed() {
+				return ErrServerClosed
 			}
-			continue
+			return err
+		}
+		s.trackConn(conn, true)
+		go func() {
+			s.processRequest(conn, bufio.NewReader(conn), handler)
+			s.trackConn(conn, false)
+			if sem != nil {
+				<-sem
+			}
+		}()
+	}
+}
+
+// Close stops accepting connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// Shutdown stops accepting connections and waits for connections to be closed.
+// Each connection is closed after its in-flight request, and response for next request
+// on idle keep-alive connection has Connection: close.
+// When ctx is done before that, remaining connections are closed forcibly and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.inShutdown = true
+	s.mu.Unlock()
+	if err := s.Close(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for {
+		if s.numConns() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			s.closeConns()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) trackConn(conn net.Conn, add bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conns == nil {
+		s.conns = map[net.Conn]struct{}{}
+	}
+	if add {
+		s.conns[conn] = struct{}{}
+	} else {
+		delete(s.conns, conn)
+	}
+}
+
+func (s *Server) numConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+func (s *Server) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *Server) shuttingDown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inShutdown
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// bodyLimits returns Limits with per-route body limit if handler has it.
+func (s *Server) bodyLimits(handler response.Handler, req *request.Request) request.Limits {
+	limits := s.Limits
+	if bl, ok := handler.(response.BodyLimiter); ok {
+		if max := bl.MaxBodyBytes(req); max != 0 {
+			limits.MaxBodyBytes = max
 		}
+	}
+	return limits
+}
 
-		log.Println(req.StartLine.ToString())
-		log.Println(req.Headers.ToString())
-		log.Println(string(req.Body))
+func (s *Server) idleTimeout() time.Duration {
+	if s.IdleTimeout == 0 {
+		return s.ReadHeaderTimeout
+	}
+	return s.IdleTimeout
+}
 
-		err = response.GetResponse(*req).Response(conn)
+func (s *Server) logger() *log.Logger {
+	if s.Logger == nil {
+		return log.Default()
+	}
+	return s.Logger
+}
+
+func (s *Server) processRequest(rwc net.Conn, reader *bufio.Reader, handler response.Handler) {
+	logger := s.logger()
+	conn := &conn{Conn: rwc}
+	for {
+		// wait for next request
+		conn.setReadTimeout(s.idleTimeout())
+		if _, err := reader.Peek(1); err != nil {
+			logger.Println("Close")
+			conn.Close()
+			return
+		}
+
+		conn.setReadTimeout(s.ReadHeaderTimeout)
+		req, err := request.ParseRequestHead(reader, s.Limits)
 		if err != nil {
 			if handleError(conn, err) {
-				log.Println("Close")
+				logger.Println("Close")
 				conn.Close()
 				return
 			}
+			continue
+		}
+
+		conn.setReadTimeout(s.ReadBodyTimeout)
+		if err := request.ReadBody(reader, req, s.bodyLimits(handler, req)); err != nil {
+			handleError(conn, err)
+			logger.Println("Close")
+			conn.Close()
+			return
+		}
+
+		logger.Println(req.StartLine.ToString())
+		logger.Println(req.Headers.ToString())
+
+		if s.shuttingDown() {
+			req.Close = true
+		}
+
+		conn.setWriteTimeout(s.WriteTimeout)
+		w := response.NewConnWriter(conn, req)
+		w.TransferCoding = s.TransferCoding
+		handler.Handle(w, req)
+		if err := req.Cleanup(); err != nil {
+			logger.Println(err)
+		}
+		if err := w.Finish(); err != nil || w.Closing() {
+			// response may be written partially
+			logger.Println("Close")
+			conn.Close()
+			return
 		}
+		conn.setWriteTimeout(0)
 
-		if req.Headers.IsConnectionClose() {
-			log.Println("Close")
+		// unread body remains on connection
+		req.Body.Close()
+		if err := req.Discard(maxDiscardBytes); err != nil {
+			logger.Println("Close")
+			conn.Close()
+			return
+		}
+		conn.setReadTimeout(0)
+
+		if req.Close {
+			logger.Println("Close")
 			conn.Close()
 			return
 		} else {
@@ -69,30 +299,24 @@ func processRequest(conn net.Conn, reader *bufio.Reader) {
 	}
 }
 
-func Stop() {
-	listener.Close()
-}
-
+// handleError responds error while parsing request and returns true if connection should be closed.
+// Connection is closed after responding error because rest of the request can't be read correctly.
 func handleError(conn net.Conn, err error) bool {
-	switch httpErr := err.(type) {
-	case *http.HTTPError:
-		res := &response.EchoResponse{Version: http.HTTP11, StatusCode: httpErr.Status, ReasonPhrase: httpErr.Msg}
-		res.Response(conn)
+	switch err.(type) {
 	case *http.WaitRequestError:
 		return false
-	default:
-		if err == io.EOF {
-			return true
-		}
-		res := &response.EchoResponse{Version: http.HTTP11, StatusCode: 503, ReasonPhrase: "Service Unavailable"}
-		res.Response(conn)
 	}
-	return false
-}
+	if err == io.EOF {
+		return true
+	}
 
-func checkError(err error) {
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Fatal error: %s\n", err.Error())
-		os.Exit(1)
+	w := response.NewConnWriter(conn, nil)
+	w.Header().Set("Connection", "close")
+	if _, ok := err.(*http.HTTPError); ok || isTimeout(err) {
+		response.Error(w, err)
+	} else {
+		w.WriteHeader(503)
 	}
+	w.Finish()
+	return true
 }
@@ -1,25 +1,59 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/inabajunmr/http11server/http/header"
+	"github.com/inabajunmr/http11server/http/request"
 	"github.com/inabajunmr/http11server/http/response"
+	"github.com/inabajunmr/http11server/http/router"
 )
 
+var PORT int
+
 func TestMain(m *testing.M) {
-	go Serve(0)
-	m.Run()
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	PORT = l.Addr().(*net.TCPAddr).Port
+	s := &Server{}
+	go s.Serve(l)
+	code := m.Run()
+	s.Close()
+	os.Exit(code)
+}
+
+// startServer starts isolated server and returns its base URL.
+func startServer(t *testing.T, s *Server) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+	t.Cleanup(func() { s.Close() })
+	return fmt.Sprintf("http://%v", l.Addr())
 }
 
 func addr() string {
@@ -39,7 +73,7 @@ func TestGet(t *testing.T) {
 	}
 
 	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip")
 
 	if resp.Header.Get("Date") == "" {
 		t.Errorf("Missing Date header.")
@@ -71,7 +105,7 @@ func TestGet_ConnectionClosed(t *testing.T) {
 	}
 
 	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip", "CONNECTION: close")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip", "Connection: close")
 }
 
 func TestGet_KeepAlive(t *testing.T) {
@@ -86,7 +120,7 @@ func TestGet_KeepAlive(t *testing.T) {
 	}
 
 	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip")
 
 	resp, err = http.Get(addr())
 	if err != nil {
@@ -99,7 +133,7 @@ func TestGet_KeepAlive(t *testing.T) {
 	}
 
 	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip")
 
 	resp, err = http.Get(addr())
 	if err != nil {
@@ -112,7 +146,7 @@ func TestGet_KeepAlive(t *testing.T) {
 	}
 
 	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip")
 }
 
 func TestGet_AcceptJson(t *testing.T) {
@@ -131,7 +165,7 @@ func TestGet_AcceptJson(t *testing.T) {
 	}
 	defer resp.Body.Close()
 	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip", "ACCEPT: application/json")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip", "Accept: application/json")
 }
 
 func TestGet_AcceptXml(t *testing.T) {
@@ -151,7 +185,7 @@ func TestGet_AcceptXml(t *testing.T) {
 	defer resp.Body.Close()
 	fmt.Println(string(b))
 	assertXmlResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip", "ACCEPT: application/xml")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip", "Accept: application/xml")
 }
 
 func TestGet_AcceptXmlAndJson(t *testing.T) {
@@ -171,7 +205,65 @@ func TestGet_AcceptXmlAndJson(t *testing.T) {
 	defer resp.Body.Close()
 	fmt.Println(string(b))
 	assertXmlResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip", "ACCEPT: application/json; q=0.5, application/xml")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip", "Accept: application/json; q=0.5, application/xml")
+}
+
+func TestGet_AcceptWildcard(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, addr(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Accept", "application/*;q=0.5, application/json;q=0.1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertXmlResponse(t, b, "", "GET", "/", "HTTP/1.1",
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip", "Accept: application/*;q=0.5, application/json;q=0.1")
+}
+
+func TestGet_ContentType(t *testing.T) {
+	for accept, expected := range map[string]string{"": "application/json", "application/xml": "application/xml; charset=utf-8"} {
+		req, err := http.NewRequest(http.MethodGet, addr(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if accept != "" {
+			req.Header.Add("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.Header.Get("Content-Type") != expected {
+			t.Errorf("Unexpected Content-Type: %v", resp.Header.Get("Content-Type"))
+		}
+	}
+}
+
+func TestGet_NotAcceptable(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, addr(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Accept", "text/html, application/json;q=0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 406 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+	if resp.Header.Get("Vary") != "accept-encoding, accept" {
+		t.Errorf("Unexpected Vary Header: %v.", resp.Header.Get("Vary"))
+	}
 }
 
 func TestGet_AccceptEncodingGzip(t *testing.T) {
@@ -202,7 +294,7 @@ func TestGet_AccceptEncodingGzip(t *testing.T) {
 	}
 
 	assertJsonResponse(t, unzip, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip")
 }
 
 func TestGet_AccceptEncodingIdentityGzip(t *testing.T) {
@@ -224,7 +316,36 @@ func TestGet_AccceptEncodingIdentityGzip(t *testing.T) {
 	}
 
 	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip; q=0.5, identity")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip; q=0.5, identity")
+}
+
+func TestGet_AccceptEncodingDeflate(t *testing.T) {
+	req, err := http.NewRequest("GET", addr(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Accept-Encoding", "br, deflate;q=0.5")
+
+	client := http.DefaultClient
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "deflate" {
+		t.Errorf("Unexpected Content-Encoding: %v", resp.Header.Get("Content-Encoding"))
+	}
+	zr, err := zlib.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: br, deflate;q=0.5")
 }
 
 func TestGet_Range1(t *testing.T) {
@@ -246,7 +367,7 @@ func TestGet_Range1(t *testing.T) {
 	}
 
 	assertJsonResponse(t, b, "", "GET", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", fmt.Sprintf("HOST: localhost:%v", PORT), "RANGE: bytes=0-")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Host: localhost:%v", PORT), "Range: bytes=0-")
 	if resp.StatusCode != 206 {
 		t.Errorf("Unexpected status: %v.", resp.StatusCode)
 	}
@@ -344,6 +465,56 @@ func TestGet_Range4(t *testing.T) {
 	}
 }
 
+func TestGet_RangeSuffixLongerThanBody(t *testing.T) {
+	req, err := http.NewRequest("GET", addr(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Range", "bytes=-100000")
+
+	client := http.DefaultClient
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 206 {
+		t.Errorf("Unexpected status: %v.", resp.StatusCode)
+	}
+	if resp.Header.Get("Content-Range") != fmt.Sprintf("bytes 0-%v/%v", len(body), len(body)) {
+		t.Errorf("Unexpected Content-Range: %v", resp.Header.Get("Content-Range"))
+	}
+}
+
+func TestGet_RangeStartAfterBody(t *testing.T) {
+	req, err := http.NewRequest("GET", addr(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Range", "bytes=100000-")
+
+	client := http.DefaultClient
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	_, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Range"), "bytes */") {
+		t.Errorf("Unexpected Content-Range: %v", resp.Header.Get("Content-Range"))
+	}
+	if resp.StatusCode != 416 {
+		t.Errorf("Unexpected status: %v.", resp.StatusCode)
+	}
+}
+
 func TestHead(t *testing.T) {
 	resp, err := http.Head(addr())
 	if err != nil {
@@ -388,6 +559,25 @@ func TestOptions(t *testing.T) {
 	}
 }
 
+func TestPut_MethodNotAllowed(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPut, addr(), strings.NewReader("aaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Allow") != "GET, POST, HEAD, OPTIONS" {
+		t.Errorf("Unexpected header: %v.", resp.Header.Get("Allow"))
+	}
+	if resp.StatusCode != 405 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+}
+
 func TestPost(t *testing.T) {
 	resp, err := http.Post(addr(),
 		"application/x-www-form-urlencoded",
@@ -403,8 +593,8 @@ func TestPost(t *testing.T) {
 	}
 
 	assertJsonResponse(t, b, "aaaaabbbbbccccc", "POST", "/", "HTTP/1.1",
-		"CONTENT-TYPE: application/x-www-form-urlencoded", "USER-AGENT: Go-http-client/1.1",
-		"CONTENT-LENGTH: 15", fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip")
+		"Content-Type: application/x-www-form-urlencoded", "User-Agent: Go-http-client/1.1",
+		"Content-Length: 15", fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip")
 }
 
 func TestPost_ContentLocation(t *testing.T) {
@@ -451,8 +641,8 @@ func TestPost_ContentEncodingGzip(t *testing.T) {
 	b, _ := ioutil.ReadAll(resp.Body)
 
 	assertJsonResponse(t, b, "hellohellohello", "POST", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", "CONTENT-LENGTH: 31",
-		fmt.Sprintf("HOST: localhost:%v", PORT), "CONTENT-ENCODING: gzip", "ACCEPT-ENCODING: gzip")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Content-Length: %v", len(gzip)),
+		fmt.Sprintf("Host: localhost:%v", PORT), "Content-Encoding: gzip", "Accept-Encoding: gzip")
 }
 
 func TestPost_ContentEncodingGzipGzip(t *testing.T) {
@@ -485,8 +675,8 @@ func TestPost_ContentEncodingGzipGzip(t *testing.T) {
 	b, _ := ioutil.ReadAll(resp.Body)
 
 	assertJsonResponse(t, b, "hellohellohello", "POST", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1", "CONTENT-LENGTH: 50",
-		fmt.Sprintf("HOST: localhost:%v", PORT), "CONTENT-ENCODING: gzip, gzip", "ACCEPT-ENCODING: gzip")
+		"User-Agent: Go-http-client/1.1", fmt.Sprintf("Content-Length: %v", len(gziped)),
+		fmt.Sprintf("Host: localhost:%v", PORT), "Content-Encoding: gzip, gzip", "Accept-Encoding: gzip")
 }
 
 func TestPost_Chunkded(t *testing.T) {
@@ -514,9 +704,568 @@ func TestPost_Chunkded(t *testing.T) {
 	b, _ := ioutil.ReadAll(resp.Body)
 
 	assertJsonResponse(t, b, "hellohellohello", "POST", "/", "HTTP/1.1",
-		"USER-AGENT: Go-http-client/1.1",
-		fmt.Sprintf("HOST: localhost:%v", PORT), "ACCEPT-ENCODING: gzip",
-		"TRANSFER-ENCODING: chunked")
+		"User-Agent: Go-http-client/1.1",
+		fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip",
+		"Transfer-Encoding: chunked")
+}
+
+func TestServe_Isolated(t *testing.T) {
+	r := router.NewRouter()
+	r.Add(request.GET, "/hello", response.EchoHandler)
+	addr1 := startServer(t, &Server{Handler: r})
+	addr2 := startServer(t, &Server{})
+
+	resp, err := http.Get(addr1 + "/world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 404 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+
+	resp, err = http.Get(addr2 + "/world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+}
+
+func TestServe_Closed(t *testing.T) {
+	s := &Server{}
+	s.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Serve(l); err != ErrServerClosed {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestListenAndServe_AddressInUse(t *testing.T) {
+	s := &Server{Addr: fmt.Sprintf(":%v", PORT)}
+	if err := s.ListenAndServe(); err == nil {
+		t.Error("Unexpected success.")
+	}
+}
+
+func TestShutdown_KeepAlive(t *testing.T) {
+	s := &Server{}
+	addr := strings.TrimPrefix(startServer(t, s), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %v\r\n\r\n", addr)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(resp.Body)
+	if resp.Close {
+		t.Error("Unexpected Connection: close.")
+	}
+
+	done := make(chan error)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		done <- s.Shutdown(ctx)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %v\r\n\r\n", addr)
+	resp, err = http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(resp.Body)
+	if !resp.Close {
+		t.Error("Connection: close is expected.")
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("Connection is expected to be closed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestShutdown_Deadline(t *testing.T) {
+	s := &Server{}
+	addr := strings.TrimPrefix(startServer(t, s), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := bufio.NewReader(conn).ReadByte(); err != io.EOF {
+		t.Errorf("Connection is expected to be closed: %v", err)
+	}
+}
+
+func TestTimeout_Idle(t *testing.T) {
+	addr := strings.TrimPrefix(startServer(t, &Server{IdleTimeout: 100 * time.Millisecond}), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	b, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 0 {
+		t.Errorf("Unexpected response: %v", string(b))
+	}
+}
+
+func TestTimeout_ReadHeader(t *testing.T) {
+	addr := strings.TrimPrefix(startServer(t, &Server{ReadHeaderTimeout: 200 * time.Millisecond}), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// slowloris
+	go func() {
+		fmt.Fprint(conn, "GET / HTTP/1.1\r\n")
+		for _, c := range "Host: localhost\r\nX-Slow: aaaaaaaaaaaaaaaaaaaa" {
+			if _, err := fmt.Fprint(conn, string(c)); err != nil {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 408 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+	if !resp.Close {
+		t.Error("Connection: close is expected.")
+	}
+}
+
+func TestTimeout_ReadBody(t *testing.T) {
+	addr := strings.TrimPrefix(startServer(t, &Server{ReadBodyTimeout: 100 * time.Millisecond}), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprint(conn, "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 10\r\n\r\naaa")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 408 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("Connection is expected to be closed: %v", err)
+	}
+}
+
+func TestLimits_RequestLine(t *testing.T) {
+	addr := strings.TrimPrefix(startServer(t, &Server{}), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go fmt.Fprintf(conn, "GET /%v HTTP/1.1\r\nHost: localhost\r\n\r\n", strings.Repeat("a", 16*1024))
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 414 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+	if !resp.Close {
+		t.Error("Connection: close is expected.")
+	}
+}
+
+func TestSmuggling_CLTE(t *testing.T) {
+	addr := strings.TrimPrefix(startServer(t, &Server{}), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go fmt.Fprint(conn, "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 6\r\nTransfer-Encoding: chunked\r\n\r\n"+
+		"0\r\n\r\nGET /smuggled HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 400 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+	if !resp.Close {
+		t.Error("Connection: close is expected.")
+	}
+	if _, err := http.ReadResponse(reader, nil); err == nil {
+		t.Error("Smuggled request is responded.")
+	}
+}
+
+func TestLimits_HeaderFields(t *testing.T) {
+	addr := startServer(t, &Server{Limits: request.Limits{MaxHeaderCount: 5}})
+	req, err := http.NewRequest("GET", addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		req.Header.Add(fmt.Sprintf("X-Header%v", i), "aaa")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 431 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+}
+
+func TestLimits_RouteBody(t *testing.T) {
+	r := router.NewRouter()
+	r.Add(request.POST, "/small", response.EchoHandler).MaxBodyBytes = 10
+	r.Add(request.POST, "/large", response.EchoHandler)
+	addr := startServer(t, &Server{Handler: r})
+
+	resp, err := http.Post(addr+"/small", "text/plain", strings.NewReader("aaaaabbbbbc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 413 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+
+	resp, err = http.Post(addr+"/large", "text/plain", strings.NewReader("aaaaabbbbbc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+}
+
+func TestPost_UnreadBody(t *testing.T) {
+	r := router.NewRouter()
+	r.AddFunc(request.POST, "/ignore", func(w response.ResponseWriter, req *request.Request) {
+		w.WriteHeader(404)
+	})
+	r.Add(request.GET, "/", response.EchoHandler)
+	addr := strings.TrimPrefix(startServer(t, &Server{Handler: r}), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	fmt.Fprint(conn, "POST /ignore HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\n5\r\naaaaa\r\n0\r\n\r\n")
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 404 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+
+	fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	resp, err = http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		t.Errorf("Unexpected status: %v", resp.StatusCode)
+	}
+}
+
+func TestResponse_Chunked(t *testing.T) {
+	r := router.NewRouter()
+	r.AddFunc(request.GET, "/stream", func(w response.ResponseWriter, req *request.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		for i := 0; i < 1000; i++ {
+			fmt.Fprintf(w, "%v\n", i)
+			if i%100 == 0 {
+				w.Flush()
+			}
+		}
+	})
+	addr := startServer(t, &Server{Handler: r})
+
+	resp, err := http.Get(addr + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Unexpected Transfer-Encoding: %v", resp.TransferEncoding)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 1000 || lines[999] != "999" {
+		t.Errorf("Unexpected body: %v", string(b))
+	}
+}
+
+func TestPost_ChunkedTrailer(t *testing.T) {
+	rd, wr := io.Pipe()
+	defer rd.Close()
+
+	req, err := http.NewRequest("POST", addr(), rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.TransferEncoding = []string{"chunked"}
+	req.Trailer = http.Header{"X-Digest": nil}
+
+	go func() {
+		wr.Write([]byte("hello"))
+		req.Trailer.Set("X-Digest", "abc")
+		wr.Close()
+	}()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+
+	res := map[string]interface{}{}
+	json.Unmarshal(b, &res)
+	if res["body"] != "hello" {
+		t.Errorf("Unexpected body:%v.", res["body"])
+	}
+	trailers, ok := res["trailers"].([]interface{})
+	if !ok || len(trailers) != 1 || trailers[0] != "X-Digest: abc" {
+		t.Errorf("Unexpected trailers:%v.", res["trailers"])
+	}
+}
+
+func TestPost_QueryAndForm(t *testing.T) {
+	resp, err := http.Post(addr()+"?a=1&b=x+y&a=2", "application/x-www-form-urlencoded",
+		strings.NewReader("name=%E3%81%82&empty="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+
+	res := struct {
+		Query map[string][]string `json:"query"`
+		Form  map[string][]string `json:"form"`
+		Body  string              `json:"body"`
+	}{}
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Query, map[string][]string{"a": {"1", "2"}, "b": {"x y"}}) {
+		t.Errorf("Unexpected query:%v.", res.Query)
+	}
+	if !reflect.DeepEqual(res.Form, map[string][]string{"name": {"あ"}, "empty": {""}}) {
+		t.Errorf("Unexpected form:%v.", res.Form)
+	}
+	if res.Body != "name=%E3%81%82&empty=" {
+		t.Errorf("Unexpected body:%v.", res.Body)
+	}
+}
+
+func TestPost_QueryAndForm_Xml(t *testing.T) {
+	req, err := http.NewRequest("POST", addr()+"?b=2&a=1", strings.NewReader("x=1&x=2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/xml")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+
+	res := response.Echo{}
+	if err := xml.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Query, []response.Param{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}) {
+		t.Errorf("Unexpected query:%v.", res.Query)
+	}
+	if !reflect.DeepEqual(res.Form, []response.Param{{Name: "x", Value: "1"}, {Name: "x", Value: "2"}}) {
+		t.Errorf("Unexpected form:%v.", res.Form)
+	}
+}
+
+func TestPost_InvalidForm(t *testing.T) {
+	resp, err := http.Post(addr(), "application/x-www-form-urlencoded", strings.NewReader("a=%zz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 400 {
+		t.Errorf("Unexpected status:%v.", resp.StatusCode)
+	}
+}
+
+func TestPost_Multipart(t *testing.T) {
+	var buffer bytes.Buffer
+	mw := multipart.NewWriter(&buffer)
+	mw.WriteField("title", "hello")
+	fw, _ := mw.CreateFormFile("upload", "dir/a.bin")
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	fw.Write(content)
+	mw.Close()
+
+	resp, err := http.Post(addr(), mw.FormDataContentType(), &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+
+	res := struct {
+		Multipart []response.Part `json:"multipart"`
+	}{}
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	expected := []response.Part{
+		{Name: "title", Size: 5, Type: "text/plain", SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{Name: "upload", FileName: "a.bin", Size: 10000, Type: "application/octet-stream", SHA256: hex.EncodeToString(sum[:])},
+	}
+	if !reflect.DeepEqual(res.Multipart, expected) {
+		t.Errorf("Unexpected multipart:%v.", res.Multipart)
+	}
+}
+
+func TestPost_InvalidMultipart(t *testing.T) {
+	resp, err := http.Post(addr(), "multipart/form-data; boundary=b", strings.NewReader("--b\r\n\r\nvalue\r\n--b--"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 400 {
+		t.Errorf("Unexpected status:%v.", resp.StatusCode)
+	}
+}
+
+func TestGet_Cookies(t *testing.T) {
+	req, err := http.NewRequest("GET", addr(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Cookie", `a=1; b="x y";c=3`)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+
+	res := struct {
+		Cookies []response.Param `json:"cookies"`
+		Headers []interface{}    `json:"headers"`
+	}{}
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	expected := []response.Param{{Name: "a", Value: "1"}, {Name: "b", Value: "x y"}, {Name: "c", Value: "3"}}
+	if !reflect.DeepEqual(res.Cookies, expected) {
+		t.Errorf("Unexpected cookies:%v.", res.Cookies)
+	}
+	// raw header is echoed as it is
+	assertHeaders(t, res.Headers, `Cookie: a=1; b="x y";c=3`, "User-Agent: Go-http-client/1.1",
+		fmt.Sprintf("Host: localhost:%v", PORT), "Accept-Encoding: gzip")
+}
+
+func TestResponse_SetCookie(t *testing.T) {
+	r := router.NewRouter()
+	r.AddFunc(request.GET, "/login", func(w response.ResponseWriter, req *request.Request) {
+		w.Header().AddSetCookie(header.SetCookie{Name: "id", Value: "abc", Path: "/", HttpOnly: true})
+		w.Header().AddSetCookie(header.SetCookie{Name: "lang", Value: "ja", MaxAge: 60})
+		w.WriteHeader(204)
+	})
+	addr := startServer(t, &Server{Handler: r})
+
+	resp, err := http.Get(addr + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if values := resp.Header.Values("Set-Cookie"); !reflect.DeepEqual(values, []string{"id=abc; Path=/; HttpOnly", "lang=ja; Max-Age=60"}) {
+		t.Errorf("Unexpected Set-Cookie: %v", values)
+	}
+}
+
+func TestResponse_Trailer(t *testing.T) {
+	r := router.NewRouter()
+	r.AddFunc(request.GET, "/digest", func(w response.ResponseWriter, req *request.Request) {
+		w.Header().Set("Trailer", "X-Digest")
+		w.Write([]byte("hello"))
+		w.Trailer().Set("X-Digest", "abc")
+	})
+	addr := startServer(t, &Server{Handler: r})
+
+	req, err := http.NewRequest("GET", addr+"/digest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("TE", "trailers")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Errorf("Unexpected body: %v", string(b))
+	}
+	if resp.Trailer.Get("X-Digest") != "abc" {
+		t.Errorf("Unexpected trailer: %v", resp.Trailer)
+	}
 }
 
 func assertJsonResponse(t *testing.T, response []byte, expectedBody string, expectedMethod string, expectedRequestTarget string, expectedVersion string, expectedHeaders ...string) {
@@ -0,0 +1,27 @@
+package http
+
+var statusText = map[int]string{
+	100: "Continue",
+	200: "OK",
+	204: "No Content",
+	206: "Partial Content",
+	400: "Bad Request",
+	404: "Not Found",
+	405: "Method Not Allowed",
+	406: "Not Acceptable",
+	408: "Request Timeout",
+	413: "Content Too Large",
+	414: "URI Too Long",
+	415: "Unsupported Media Type",
+	416: "Range Not Satisfiable",
+	417: "Expectation Failed",
+	431: "Request Header Fields Too Large",
+	500: "Internal Server Error",
+	501: "Not Implemented",
+	503: "Service Unavailable",
+}
+
+// StatusText returns reason phrase for status code.
+func StatusText(code int) string {
+	return statusText[code]
+}
@@ -1,7 +1,39 @@
 package main
 
-import "github.com/inabajunmr/http11server/http/server"
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/inabajunmr/http11server/http/server"
+)
 
 func main() {
-	server.Serve(80)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	s := &server.Server{
+		Addr:              ":80",
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadBodyTimeout:   30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+	go func() {
+		if err := s.ListenAndServe(); err != server.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutdown")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
 }